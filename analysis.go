@@ -0,0 +1,293 @@
+package stank
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Analysis bundles together a file's smell with its contents,
+// so that the file need only be read once, no matter how many checks consume it.
+//
+// Contents are collected for POSIXy and alternative shell scripts, and left empty otherwise.
+type Analysis struct {
+	// Smell describes the file.
+	Smell Smell
+
+	// Bytes holds the raw file contents, including any BOM.
+	Bytes []byte
+
+	// Lines holds the file contents split into lines, without line endings.
+	Lines []string
+
+	// Configs lists the project configuration files governing the file, outermost first.
+	Configs []Config
+
+	// variant selects the shell parser language.
+	variant syntax.LangVariant
+
+	// fallback permits the in-process parser to stand in for a shell missing from PATH.
+	fallback bool
+
+	// parsed caches the syntax tree, shared among copies.
+	parsed *parsed
+
+	// scanned caches the bashisms, shared among copies.
+	scanned *scanned
+}
+
+// parsed caches a syntax tree.
+type parsed struct {
+	once sync.Once
+	file *syntax.File
+	err  error
+}
+
+// scanned caches the bashisms of a script.
+type scanned struct {
+	once     sync.Once
+	bashisms []Bashism
+	err      error
+}
+
+// NewAnalysis constructs an Analysis from a smell and the corresponding file contents.
+//
+// The sniffer selects a shell parser variant for the interpreter.
+func (o Sniffer) NewAnalysis(smell Smell, contents []byte) Analysis {
+	_, fallback := o.FallbackVariant(smell.Interpreter)
+
+	return Analysis{
+		Smell:    smell,
+		Bytes:    contents,
+		Lines:    SplitLines(contents),
+		variant:  o.Variant(smell.Interpreter),
+		fallback: fallback,
+		parsed:   &parsed{},
+		scanned:  &scanned{},
+	}
+}
+
+// newAnalysis constructs an Analysis, inferring the dialect of POSIXy scripts given DialectCheck.
+func (o Sniffer) newAnalysis(smell Smell, contents []byte, config SniffConfig) Analysis {
+	analysis := o.NewAnalysis(smell, contents)
+
+	if config.DialectCheck && smell.POSIXy {
+		analysis.Smell.Dialect = analysis.Dialect()
+	}
+
+	return analysis
+}
+
+// Dialect infers the least powerful dialect which parses the contents, per Dialects,
+// such as "posix", "mksh", "bash", or "zsh".
+// Contents which no dialect parses yield an empty string.
+//
+// The cached syntax tree stands in for the interpreter's own variant.
+// Analyses lacking contents yield an empty string.
+func (o Analysis) Dialect() string {
+	if o.parsed == nil {
+		return ""
+	}
+
+	_, err := o.File()
+
+	for _, variant := range Dialects {
+		if variant == o.variant {
+			if err == nil {
+				return variant.String()
+			}
+
+			continue
+		}
+
+		parser := syntax.NewParser(syntax.Variant(variant))
+
+		if _, err2 := parser.Parse(bytes.NewReader(o.Bytes), ""); err2 == nil {
+			return variant.String()
+		}
+	}
+
+	return ""
+}
+
+// File parses the contents as a shell script, on first use.
+//
+// Subsequent calls return the same syntax tree.
+// Analyses lacking contents report io.EOF.
+func (o Analysis) File() (*syntax.File, error) {
+	if o.parsed == nil {
+		return nil, io.EOF
+	}
+
+	o.parsed.once.Do(func() {
+		parser := syntax.NewParser(syntax.Variant(o.variant))
+		o.parsed.file, o.parsed.err = parser.Parse(bytes.NewReader(o.Bytes), o.Smell.Path)
+	})
+
+	return o.parsed.file, o.parsed.err
+}
+
+// ParserCheckSyntax validates the script contents in-process, reusing the parsed syntax tree.
+func (o Analysis) ParserCheckSyntax() error {
+	if _, err := o.File(); err != nil {
+		return BackendError{Backend: fmt.Sprintf("%v %v", ParserBackend, o.variant), Err: err}
+	}
+
+	return nil
+}
+
+// CheckSyntax validates the script contents.
+//
+// POSIX sh scripts reuse the parsed syntax tree, as do shells missing from PATH, given a FallbackVariant.
+// Other interpreters delegate to Interpreter2SyntaxValidator.
+func (o Analysis) CheckSyntax() error {
+	if o.Smell.Interpreter == "sh" || o.Smell.Interpreter == "generic-sh" {
+		return o.ParserCheckSyntax()
+	}
+
+	validator, ok := Interpreter2SyntaxValidator()[o.Smell.Interpreter]
+
+	if !ok {
+		return nil
+	}
+
+	if o.fallback {
+		if _, err := exec.LookPath(o.Smell.Interpreter); err != nil {
+			return o.ParserCheckSyntax()
+		}
+	}
+
+	return validator(o.Smell)
+}
+
+// SplitLines divides contents into lines, in the manner of bufio.ScanLines.
+//
+// Line endings, including any trailing CR, are removed.
+func SplitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
+
+// Variant selects a shell parser language variant for an interpreter.
+//
+// Unrecognized interpreters default to strict POSIX sh.
+func (o Sniffer) Variant(interpreter string) syntax.LangVariant {
+	switch {
+	case o.FullBashInterpreters[interpreter]:
+		return syntax.LangBash
+	case o.KshInterpreters[interpreter]:
+		return syntax.LangMirBSDKorn
+	case interpreter == "zsh":
+		return syntax.LangZsh
+	case interpreter == "bats":
+		return syntax.LangBats
+	default:
+		return syntax.LangPOSIX
+	}
+}
+
+// Analyze sniffs a file path, reading the contents of any scripts.
+//
+// The file is opened once, and scripts are read once in full.
+// As with Sniff, io.EOF may accompany a valid analysis.
+//
+// Analyze is a thin wrapper around AnalyzeFS, by way of os.DirFS.
+func (o Sniffer) Analyze(pth string, config SniffConfig) (Analysis, error) {
+	fsys, name := osFS(pth)
+	analysis, err := o.AnalyzeFS(fsys, name, config)
+	analysis.Smell.Path = pth
+	return analysis, err
+}
+
+// AnalyzeFS sniffs a named file within a file system, reading the contents of any scripts.
+//
+// See Analyze.
+func (o Sniffer) AnalyzeFS(fsys fs.FS, name string, config SniffConfig) (Analysis, error) {
+	return o.analyzeFS(fsys, name, config, false, true)
+}
+
+// analyzeFS implements SniffFS and AnalyzeFS, optionally following symlinks and reading script contents.
+func (o Sniffer) analyzeFS(fsys fs.FS, name string, config SniffConfig, followSymlinks bool, read bool) (Analysis, error) {
+	// Dialect inference consumes the full contents.
+	read = read || config.DialectCheck
+	stat := fs.Lstat
+
+	if followSymlinks {
+		stat = fs.Stat
+	}
+
+	// Attempt to short-circuit for directories
+	fi, err := stat(fsys, name)
+
+	if err != nil {
+		return Analysis{Smell: Smell{Path: name}}, err
+	}
+
+	mode := fi.Mode()
+
+	if mode.IsDir() || mode&os.ModeSymlink != 0 {
+		smell, err2 := o.sniffReader(name, mode, bytes.NewReader(nil), 0, config)
+		return Analysis{Smell: smell}, err2
+	}
+
+	fd, err := fsys.Open(name)
+
+	if err != nil {
+		return Analysis{Smell: Smell{Path: name}}, err
+	}
+
+	defer func() {
+		if err2 := fd.Close(); err2 != nil {
+			log.Panic(err2)
+		}
+	}()
+
+	ra, ok := fd.(io.ReaderAt)
+
+	if !ok {
+		contents, err2 := io.ReadAll(fd)
+
+		if err2 != nil {
+			return Analysis{Smell: Smell{Path: name}}, err2
+		}
+
+		smell, err2 := o.sniffReader(name, mode, bytes.NewReader(contents), int64(len(contents)), config)
+
+		if !read || !(smell.POSIXy || smell.AltShellScript) {
+			return Analysis{Smell: smell}, err2
+		}
+
+		return o.newAnalysis(smell, contents, config), err2
+	}
+
+	smell, err := o.sniffReader(name, mode, ra, fi.Size(), config)
+
+	if !read || !(smell.POSIXy || smell.AltShellScript) || (err != nil && err != io.EOF) {
+		return Analysis{Smell: smell}, err
+	}
+
+	contents := make([]byte, fi.Size())
+
+	if _, err2 := io.ReadFull(io.NewSectionReader(ra, 0, fi.Size()), contents); err2 != nil {
+		return Analysis{Smell: smell}, err2
+	}
+
+	return o.newAnalysis(smell, contents, config), err
+}
@@ -0,0 +1,60 @@
+package stank_test
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestAnalyzeSharesContents(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	analysis, err := sniffer.Analyze(filepath.Join("examples", "hello-crlf.sh"), stank.SniffConfig{})
+
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+
+	if len(analysis.Bytes) == 0 {
+		t.Fatalf("expected script contents")
+	}
+
+	for _, line := range analysis.Lines {
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			t.Errorf("expected lines without CR, got %q", line)
+		}
+	}
+
+	file, err := analysis.File()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file2, _ := analysis.File()
+
+	if file != file2 {
+		t.Errorf("expected syntax tree to be parsed once")
+	}
+
+	analysis, err = sniffer.Analyze(filepath.Join("examples", "hello.py"), stank.SniffConfig{})
+
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+
+	if analysis.Bytes != nil {
+		t.Errorf("expected nonscript contents to be skipped")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	expected := []string{"#!/bin/sh", "", "echo hi"}
+
+	if observed := stank.SplitLines([]byte("#!/bin/sh\r\n\r\necho hi\n")); !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected %q, got %q", expected, observed)
+	}
+}
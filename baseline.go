@@ -0,0 +1,148 @@
+package stank
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// BaselineVersion denotes the current baseline file format.
+const BaselineVersion = 1
+
+// Fingerprint identifies a diagnostic by rule, path, and the normalized content of the offending line,
+// so that fingerprints survive insertions and deletions elsewhere in the file.
+//
+// Whitespace is collapsed, and findings about the file as a whole hash an empty line.
+func (o Analysis) Fingerprint(diagnostic Diagnostic) string {
+	var content string
+
+	if diagnostic.Line > 0 && diagnostic.Line <= len(o.Lines) {
+		content = strings.Join(strings.Fields(o.Lines[diagnostic.Line-1]), " ")
+	}
+
+	pth := filepath.ToSlash(filepath.Clean(diagnostic.Path))
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v\x00%v\x00%v", diagnostic.Rule, pth, content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// BaselineFinding records the number of known diagnostics sharing a fingerprint.
+type BaselineFinding struct {
+	// Fingerprint identifies the diagnostics.
+	Fingerprint string `json:"fingerprint"`
+
+	// Rule identifies the check, for human readers.
+	Rule string `json:"rule"`
+
+	// Path locates the file, for human readers.
+	Path string `json:"path"`
+
+	// Message describes the first such diagnostic, for human readers.
+	Message string `json:"message"`
+
+	// Count tallies the diagnostics.
+	Count int `json:"count"`
+}
+
+// Baseline records known diagnostics, so that only new diagnostics fail a lint.
+type Baseline struct {
+	// Version denotes the file format.
+	Version int `json:"version"`
+
+	// Findings lists the known diagnostics.
+	Findings []BaselineFinding `json:"findings"`
+
+	// index locates Findings by fingerprint.
+	index map[string]int
+}
+
+// NewBaseline constructs an empty Baseline.
+func NewBaseline() Baseline {
+	return Baseline{Version: BaselineVersion, Findings: []BaselineFinding{}}
+}
+
+// Add records a fingerprinted diagnostic.
+func (o *Baseline) Add(diagnostic Diagnostic) {
+	if o.index == nil || len(o.index) != len(o.Findings) {
+		o.index = make(map[string]int, len(o.Findings))
+
+		for i, finding := range o.Findings {
+			o.index[finding.Fingerprint] = i
+		}
+	}
+
+	if i, ok := o.index[diagnostic.Fingerprint]; ok {
+		o.Findings[i].Count++
+		return
+	}
+
+	o.index[diagnostic.Fingerprint] = len(o.Findings)
+	o.Findings = append(o.Findings, BaselineFinding{
+		Fingerprint: diagnostic.Fingerprint,
+		Rule:        diagnostic.Rule,
+		Path:        filepath.ToSlash(filepath.Clean(diagnostic.Path)),
+		Message:     diagnostic.Message,
+		Count:       1,
+	})
+}
+
+// Counts tallies the known diagnostics, by fingerprint.
+func (o Baseline) Counts() map[string]int {
+	counts := map[string]int{}
+
+	for _, finding := range o.Findings {
+		counts[finding.Fingerprint] += finding.Count
+	}
+
+	return counts
+}
+
+// LoadBaseline parses a baseline file.
+func LoadBaseline(pth string) (Baseline, error) {
+	var baseline Baseline
+
+	contents, err := os.ReadFile(pth)
+
+	if err != nil {
+		return baseline, err
+	}
+
+	if err := json.Unmarshal(contents, &baseline); err != nil {
+		return baseline, fmt.Errorf("%v: %v", pth, err)
+	}
+
+	if baseline.Version != BaselineVersion {
+		return baseline, fmt.Errorf("%v: unsupported baseline version: %d", pth, baseline.Version)
+	}
+
+	return baseline, nil
+}
+
+// WriteBaseline renders a baseline file.
+//
+// Findings are sorted by path, rule, and fingerprint, for stable diffs.
+// The given baseline is left in insertion order.
+func WriteBaseline(pth string, baseline Baseline) error {
+	baseline.Findings = slices.Clone(baseline.Findings)
+
+	slices.SortFunc(baseline.Findings, func(a BaselineFinding, b BaselineFinding) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Rule, b.Rule),
+			cmp.Compare(a.Fingerprint, b.Fingerprint),
+		)
+	})
+
+	contents, err := json.MarshalIndent(baseline, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(pth, append(contents, '\n'), 0644)
+}
@@ -0,0 +1,100 @@
+package stank_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestFingerprintSurvivesLineShifts(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	smell := stank.Smell{Path: "run", POSIXy: true}
+	before := sniffer.NewAnalysis(smell, []byte("#!/bin/sh\ntrap 'echo bye' EXIT\nexec true\n"))
+	after := sniffer.NewAnalysis(smell, []byte("#!/bin/sh\n\necho hi\ntrap 'echo bye' EXIT\n  exec   true\n"))
+
+	diagnostic := stank.NewDiagnostic(stank.RuleTrapHazards, "run", "exec discards traps")
+	diagnostic.Line = 3
+	fingerprint := before.Fingerprint(diagnostic)
+	diagnostic.Line = 5
+
+	if after.Fingerprint(diagnostic) != fingerprint {
+		t.Errorf("expected fingerprint to survive line shifts and whitespace changes")
+	}
+
+	diagnostic.Line = 4
+
+	if after.Fingerprint(diagnostic) == fingerprint {
+		t.Errorf("expected fingerprint to vary with line content")
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := stank.NewBaseline()
+
+	for _, fingerprint := range []string{"b", "a", "b"} {
+		diagnostic := stank.NewDiagnostic(stank.RuleSafetyFlags, "run", "Control program flow like `set -euf` at the top of executable scripts")
+		diagnostic.Fingerprint = fingerprint
+		baseline.Add(diagnostic)
+	}
+
+	if err := stank.WriteBaseline(pth, baseline); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := stank.LoadBaseline(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := loaded.Counts()
+
+	if len(counts) != 2 || counts["a"] != 1 || counts["b"] != 2 {
+		t.Errorf("expected counts a=1, b=2, got %v", counts)
+	}
+
+	if loaded.Findings[0].Fingerprint != "a" {
+		t.Errorf("expected sorted findings, got %v", loaded.Findings)
+	}
+}
+
+func TestBaselineAddCountsAfterWrite(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := stank.NewBaseline()
+
+	add := func(fingerprints ...string) {
+		for _, fingerprint := range fingerprints {
+			diagnostic := stank.NewDiagnostic(stank.RuleSafetyFlags, "run", "Control program flow like `set -euf` at the top of executable scripts")
+			diagnostic.Fingerprint = fingerprint
+			baseline.Add(diagnostic)
+		}
+	}
+
+	add("c", "b", "a", "c")
+
+	if err := stank.WriteBaseline(pth, baseline); err != nil {
+		t.Fatal(err)
+	}
+
+	add("a", "d")
+
+	if counts := baseline.Counts(); len(counts) != 4 || counts["a"] != 2 || counts["c"] != 2 || counts["b"] != 1 || counts["d"] != 1 {
+		t.Errorf("expected counts a=2, b=1, c=2, d=1, got %v", counts)
+	}
+
+	loaded, err := stank.LoadBaseline(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostic := stank.NewDiagnostic(stank.RuleSafetyFlags, "run", "")
+	diagnostic.Fingerprint = "b"
+	loaded.Add(diagnostic)
+
+	if counts := loaded.Counts(); counts["b"] != 2 || len(loaded.Findings) != 3 {
+		t.Errorf("expected loaded findings indexed, got %v", loaded.Findings)
+	}
+}
@@ -0,0 +1,196 @@
+package stank
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"slices"
+	"sync"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// POSIXShInterpreters collects interpreters which declare strictly POSIX sh scripts.
+var POSIXShInterpreters = sync.OnceValue(func() map[string]bool {
+	return map[string]bool{
+		"ash":        true,
+		"dash":       true,
+		"generic-sh": true,
+		"posh":       true,
+		"sh":         true,
+	}
+})
+
+// Bashism locates a non-POSIX construct.
+type Bashism struct {
+	// Line is the 1-based line number.
+	Line int
+
+	// Column is the 1-based column number.
+	Column int
+
+	// Construct names the offending syntax.
+	Construct string
+
+	// Replacement suggests a POSIX alternative.
+	Replacement string
+}
+
+// newBashism constructs a Bashism located at a syntax node position.
+func newBashism(pos syntax.Pos, construct string, replacement string) Bashism {
+	return Bashism{
+		Line:        int(pos.Line()),
+		Column:      int(pos.Col()),
+		Construct:   construct,
+		Replacement: replacement,
+	}
+}
+
+// FindBashisms lists the non-POSIX constructs of a syntax tree parsed with syntax.LangBash,
+// in the manner of checkbashisms.
+func FindBashisms(file *syntax.File) []Bashism {
+	var bashisms []Bashism
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.TestClause:
+			bashisms = append(bashisms, newBashism(node.Pos(), "[[ ]]", "Use [ ] or case"))
+		case *syntax.ArithmCmd:
+			bashisms = append(bashisms, newBashism(node.Pos(), "(( ))", "Use [ \"$(( ))\" -ne 0 ]"))
+		case *syntax.LetClause:
+			bashisms = append(bashisms, newBashism(node.Pos(), "let", "Use $(( )) arithmetic expansion"))
+		case *syntax.CStyleLoop:
+			bashisms = append(bashisms, newBashism(node.Pos(), "for (( ))", "Use a while loop with a counter"))
+		case *syntax.FuncDecl:
+			if node.RsrvWord {
+				bashisms = append(bashisms, newBashism(node.Pos(), "function keyword", "Declare functions like f() { ... }"))
+			}
+		case *syntax.DeclClause:
+			switch node.Variant.Value {
+			case "local":
+				bashisms = append(bashisms, newBashism(node.Pos(), "local", "Use uniquely prefixed global variables, or a subshell function body"))
+			case "declare", "typeset", "nameref":
+				bashisms = append(bashisms, newBashism(node.Pos(), node.Variant.Value, "Use plain assignments"))
+			}
+		case *syntax.Assign:
+			if node.Array != nil || node.Index != nil {
+				bashisms = append(bashisms, newBashism(node.Pos(), "array", "Use set -- or delimited strings"))
+			}
+		case *syntax.ParamExp:
+			switch {
+			case node.Index != nil:
+				bashisms = append(bashisms, newBashism(node.Pos(), "array expansion", "Use set -- and \"$@\""))
+			case node.Excl:
+				bashisms = append(bashisms, newBashism(node.Pos(), "${!var} indirection", "Use eval with care"))
+			case node.Slice != nil:
+				bashisms = append(bashisms, newBashism(node.Pos(), "${var:offset} substring", "Use cut, or ${var#prefix} / ${var%suffix}"))
+			case node.Repl != nil:
+				bashisms = append(bashisms, newBashism(node.Pos(), "${var/pattern/string} replacement", "Use sed"))
+			}
+		case *syntax.SglQuoted:
+			if node.Dollar {
+				bashisms = append(bashisms, newBashism(node.Pos(), "$'...' quoting", "Use printf"))
+			}
+		case *syntax.DblQuoted:
+			if node.Dollar {
+				bashisms = append(bashisms, newBashism(node.Pos(), "$\"...\" localization", "Use plain double quotes"))
+			}
+		case *syntax.ProcSubst:
+			bashisms = append(bashisms, newBashism(node.Pos(), "process substitution", "Use a temporary file or a pipe"))
+		case *syntax.ExtGlob:
+			bashisms = append(bashisms, newBashism(node.Pos(), "extended glob", "Use case patterns, or several globs"))
+		case *syntax.Redirect:
+			switch node.Op {
+			case syntax.RdrAll:
+				bashisms = append(bashisms, newBashism(node.Pos(), "&>", "Use >file 2>&1"))
+			case syntax.AppAll:
+				bashisms = append(bashisms, newBashism(node.Pos(), "&>>", "Use >>file 2>&1"))
+			case syntax.WordHdoc:
+				bashisms = append(bashisms, newBashism(node.Pos(), "<<< here-string", "Use a here-document, or printf piped into the command"))
+			}
+		case *syntax.CallExpr:
+			bashisms = append(bashisms, callBashisms(node)...)
+		case *syntax.Word:
+			if hasBraceExpansion(node) {
+				bashisms = append(bashisms, newBashism(node.Pos(), "brace expansion", "Spell out each word, or use a loop"))
+			}
+		}
+
+		return true
+	})
+
+	slices.SortStableFunc(bashisms, func(a Bashism, b Bashism) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	return bashisms
+}
+
+// hasBraceExpansion reports whether a word features valid brace expansions.
+//
+// A shallow copy is split, as Walk rejects BraceExp nodes.
+func hasBraceExpansion(word *syntax.Word) bool {
+	split := &syntax.Word{Parts: word.Parts}
+
+	if !syntax.SplitBraces(split) {
+		return false
+	}
+
+	return slices.ContainsFunc(split.Parts, func(part syntax.WordPart) bool {
+		_, ok := part.(*syntax.BraceExp)
+		return ok
+	})
+}
+
+// callBashisms lists the non-POSIX usage of simple commands.
+func callBashisms(call *syntax.CallExpr) []Bashism {
+	if len(call.Args) == 0 {
+		return nil
+	}
+
+	var bashisms []Bashism
+
+	switch call.Args[0].Lit() {
+	case "source":
+		bashisms = append(bashisms, newBashism(call.Args[0].Pos(), "source", "Use ."))
+	case "[", "test":
+		for _, arg := range call.Args[1:] {
+			if arg.Lit() == "==" {
+				bashisms = append(bashisms, newBashism(arg.Pos(), "== in test", "Use ="))
+			}
+		}
+	}
+
+	return bashisms
+}
+
+// Bashisms parses the contents as a bash script, listing any non-POSIX constructs, on first use.
+//
+// Subsequent calls return the same bashisms. Bash scripts reuse the parsed syntax tree.
+// Analyses lacking contents report io.EOF.
+func (o Analysis) Bashisms() ([]Bashism, error) {
+	if o.scanned == nil {
+		return nil, io.EOF
+	}
+
+	o.scanned.once.Do(func() {
+		var file *syntax.File
+		var err error
+
+		if o.variant == syntax.LangBash {
+			file, err = o.File()
+		} else {
+			parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+			file, err = parser.Parse(bytes.NewReader(o.Bytes), o.Smell.Path)
+		}
+
+		if err != nil {
+			o.scanned.err = err
+			return
+		}
+
+		o.scanned.bashisms = FindBashisms(file)
+	})
+
+	return o.scanned.bashisms, o.scanned.err
+}
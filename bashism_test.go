@@ -0,0 +1,92 @@
+package stank_test
+
+import (
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestBashisms(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		contents  string
+		construct string
+		line      int
+		column    int
+	}{
+		{"if [[ -n \"$A\" ]]; then :; fi\n", "[[ ]]", 1, 4},
+		{"a=(1 2)\n", "array", 1, 1},
+		{"echo \"${a[0]}\"\n", "array expansion", 1, 7},
+		{"function f {\n\t:\n}\n", "function keyword", 1, 1},
+		{"source ./lib.sh\n", "source", 1, 1},
+		{"[ \"$a\" == b ]\n", "== in test", 1, 8},
+		{"f() {\n\tlocal a=1\n}\n", "local", 2, 2},
+		{"printf $'\\t'\n", "$'...' quoting", 1, 8},
+		{"diff <(ls a) <(ls b)\n", "process substitution", 1, 6},
+		{"ls &>/dev/null\n", "&>", 1, 4},
+		{"echo {a,b}\n", "brace expansion", 1, 6},
+	} {
+		analysis := sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: "sh"}, []byte(tc.contents))
+		bashisms, err := analysis.Bashisms()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bashisms) == 0 {
+			t.Errorf("expected %v bashism in %q", tc.construct, tc.contents)
+			continue
+		}
+
+		bashism := bashisms[0]
+
+		if bashism.Construct != tc.construct || bashism.Line != tc.line || bashism.Column != tc.column {
+			t.Errorf("expected %v at %v:%v in %q, got %v", tc.construct, tc.line, tc.column, tc.contents, bashism)
+		}
+
+		if bashism.Replacement == "" {
+			t.Errorf("expected a POSIX replacement for %v", tc.construct)
+		}
+	}
+}
+
+func TestBashismsIgnorePOSIX(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	contents := "#!/bin/sh\nf() {\n\t[ \"$1\" = b ] && echo \"${1#b}\" {}\n}\nfind . -exec echo {} \\;\n"
+	analysis := sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: "sh"}, []byte(contents))
+	bashisms, err := analysis.Bashisms()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bashisms) != 0 {
+		t.Errorf("expected no bashisms, got %v", bashisms)
+	}
+}
+
+func TestBashismsCached(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	analysis := sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: "bash"}, []byte("#!/bin/bash\n[[ -n \"$1\" ]]\n"))
+	bashisms, err := analysis.Bashisms()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicate := analysis
+	cached, err := duplicate.Bashisms()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bashisms) != 1 || len(cached) != 1 || &bashisms[0] != &cached[0] {
+		t.Errorf("expected copies to share the cached bashisms, got %v and %v", bashisms, cached)
+	}
+
+	if _, err := (stank.Analysis{}).Bashisms(); err == nil {
+		t.Errorf("expected analyses lacking contents to report an error")
+	}
+}
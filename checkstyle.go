@@ -0,0 +1,87 @@
+package stank
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// CheckstyleResult models a Checkstyle XML report.
+type CheckstyleResult struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []CheckstyleFile `xml:"file"`
+}
+
+// CheckstyleFile models the findings for one file.
+type CheckstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []CheckstyleError `xml:"error"`
+}
+
+// CheckstyleError models a finding.
+type CheckstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// CheckstyleReporter renders diagnostics as Checkstyle XML, grouped by file.
+//
+// Diagnostics are buffered until Close.
+type CheckstyleReporter struct {
+	// Out receives the report.
+	Out io.Writer
+
+	// Result collects the findings.
+	Result CheckstyleResult
+}
+
+// NewCheckstyleReporter constructs a CheckstyleReporter.
+func NewCheckstyleReporter(out io.Writer) *CheckstyleReporter {
+	return &CheckstyleReporter{Out: out, Result: CheckstyleResult{Version: "4.3"}}
+}
+
+// Report buffers a diagnostic.
+func (o *CheckstyleReporter) Report(diagnostic Diagnostic) error {
+	files := o.Result.Files
+
+	if len(files) == 0 || files[len(files)-1].Name != diagnostic.Path {
+		files = append(files, CheckstyleFile{Name: diagnostic.Path})
+	}
+
+	file := &files[len(files)-1]
+	file.Errors = append(file.Errors, CheckstyleError{
+		Line:     diagnostic.Line,
+		Column:   diagnostic.Column,
+		Severity: diagnostic.Severity.String(),
+		Message:  diagnostic.Message,
+		Source:   "funk." + diagnostic.Rule,
+	})
+
+	o.Result.Files = files
+	return nil
+}
+
+// Close renders the Checkstyle XML.
+func (o *CheckstyleReporter) Close() error {
+	return writeXML(o.Out, o.Result)
+}
+
+// writeXML renders an indented XML document.
+func writeXML(out io.Writer, v any) error {
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, "\n")
+	return err
+}
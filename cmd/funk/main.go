@@ -3,539 +3,905 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"log"
+	"maps"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/mcandre/stank"
+	"mvdan.cc/sh/v3/syntax"
 )
 
-var flagEOL = flag.Bool("eol", true, "Report presence/absence of final end of line sequence")
-var flagCR = flag.Bool("cr", true, "Report presence/absence of final end of line sequence")
-var flagModulino = flag.Bool("modulino", false, "Enforce strict separation of application scripts vs. library scripts")
+var flagEOL = flag.Bool("eol", true, "Report presence/absence of final end of line sequence (Legacy alias for the eol rule)")
+var flagCR = flag.Bool("cr", true, "Report presence/absence of any CR/CRLF's (Legacy alias for the cr rule)")
+var flagModulino = flag.Bool("modulino", false, "Enforce strict separation of application scripts vs. library scripts (Legacy alias for the modulino rule)")
+var flagProfile = flag.String("profile", "", fmt.Sprintf("Base rule selection (%v)", strings.Join(slices.Sorted(maps.Keys(stank.Profiles())), ", ")))
+var flagEnable = flag.String("enable", "", "Enable the given rule(s) (Comma separated, or all)")
+var flagDisable = flag.String("disable", "", "Disable the given rule(s) (Comma separated, or all)")
+var flagSeverity = flag.String("severity", "", "Override rule severities, like eol=error,modulino=warning (Comma separated)")
+var flagAltSyntax = flag.Bool("alt-syntax", false, "Validate alternative shell scripts, such as fish and csh scripts (Alias for the alt-syntax rule)")
+var flagFmt = flag.Bool("fmt", false, "Report scripts whose formatting differs from the canonical print, per the [funk.format] configuration (Alias for the format rule)")
+var flagReportUnusedSuppressions = flag.Bool("report-unused-suppressions", false, "Report funk:disable directives which suppress nothing (Alias for the unused-suppression rule)")
+var flagBaseline = flag.String("baseline", "", "Ignore findings recorded in the given baseline file")
+var flagWriteBaseline = flag.String("write-baseline", "", "Record all findings to the given baseline file, rather than reporting them")
+var flagFix = flag.Bool("fix", false, "Rewrite files in place to resolve mechanical findings")
+var flagDiff = flag.Bool("diff", false, "Print unified diffs of pending fixes, without writing files")
+var flagShebangPrefix = flag.String("shebang-prefix", "", fmt.Sprintf("Precede interpreter names with this prefix when fixing shebangs (Default %q)", stank.DefaultShebangPrefix))
+var flagSyntaxTimeout = flag.Duration("syntax-timeout", stank.SyntaxValidatorTimeout, "Abandon external syntax validators after this duration")
+var flagMatrix = flag.String("matrix", "", "Report which of the given installed shells accept each POSIXy script, rather than linting (Comma separated, like dash,bash,mksh,zsh,busybox)")
+var flagMatrixFormat = flag.String("matrix-format", "table", "Syntax matrix output format (table, json)")
+var flagListRules = flag.Bool("list-rules", false, "Show rule IDs, default severities, and descriptions")
+var flagGitIgnore = flag.Bool("gitignore", true, "Skip paths excluded by .gitignore files")
+var flagFormat = flag.String("format", "text", fmt.Sprintf("Output format (%v)", strings.Join(stank.ReporterFormats(), ", ")))
+var flagJobs = flag.Int("jobs", NewFunk().Jobs, "Lint up to this many files concurrently")
 var flagHelp = flag.Bool("help", false, "Show usage information")
 var flagVersion = flag.Bool("version", false, "Show version information")
 
 // Funk holds configuration for a funky walk.
 type Funk struct {
-	// EOLCheck enables final end of line checks.
-	EOLCheck bool
+	// Rules selects the active checks.
+	Rules stank.RuleSet
 
-	// CRCheck enables carriage return checks.
-	CRCheck bool
+	// RuleConfig customizes Rules, taking precedence over any project configuration.
+	RuleConfig stank.FunkConfig
 
-	// ModulinoCheck enables modulino checks.
-	ModulinoCheck bool
-
-	// FoundOdor indicates the presence of warnings.
+	// FoundOdor indicates the presence of warnings or errors.
 	FoundOdor bool
 
-	// sniffer analyzes files.
-	sniffer stank.Sniffer
+	// Baseline counts known findings yet to be matched, by fingerprint.
+	Baseline map[string]int
+
+	// NewBaseline optionally records findings, rather than reporting them.
+	NewBaseline *stank.Baseline
+
+	// Fix rewrites files to resolve fixable findings.
+	Fix bool
+
+	// Diff prints pending fixes as unified diffs, rather than writing files or reporting findings.
+	Diff bool
+
+	// ShebangPrefix precedes the interpreter name in repaired shebangs.
+	ShebangPrefix string
+
+	// InterpreterPairings lists additional shebang interpreters acceptable for each lowercase extension.
+	InterpreterPairings map[string][]string
+
+	// FormatOptions configures the canonical printing for the format rule.
+	FormatOptions stank.FormatOptions
+
+	// Jobs lints up to this many files concurrently, one per CPU by default.
+	// Walks sniff files serially, feeding this single pool of lint workers.
+	Jobs int
+
+	// Reporter renders diagnostics.
+	Reporter stank.Reporter
+
+	// Sniffer applies any project classification tables atop WalkConfig.Sniffer, per Configure.
+	Sniffer stank.Sniffer
+
+	// WalkConfig controls file tree traversal and sniffing.
+	WalkConfig stank.WalkConfig
 }
 
 // NewFunk constructs a Funk.
 func NewFunk() Funk {
 	var funk Funk
-	funk.sniffer = stank.NewSniffer()
+	funk.Rules = stank.NewRuleSet()
+	funk.ShebangPrefix = stank.DefaultShebangPrefix
+	funk.Jobs = runtime.NumCPU()
+	funk.Reporter = stank.NewTextReporter(os.Stdout)
+	funk.WalkConfig = stank.NewWalkConfig()
+	funk.Sniffer = funk.WalkConfig.Sniffer
 	return funk
 }
 
+// lineOf computes the 1-based line number of a byte offset.
+func lineOf(analysis stank.Analysis, offset int) int {
+	return bytes.Count(analysis.Bytes[:offset], []byte{'\n'}) + 1
+}
+
 // CheckEOL analyzes POSIXy scripts for the presence/absence of a final end of line sequence such as \n at the end of a file, \r\n, etc.
-func CheckEOL(smell stank.Smell) bool {
+func (o Funk) CheckEOL(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
 	if smell.FinalEOL != nil && !(*smell.FinalEOL) {
-		fmt.Printf("Missing final end of line sequence: %s\n", smell.Path)
-		return true
+		diagnostic := stank.NewDiagnostic(stank.RuleEOL, smell.Path, "Missing final end of line sequence")
+		diagnostic.Line = len(analysis.Lines)
+		diagnostic.Fix = "Append a final LF"
+		return []stank.Diagnostic{diagnostic}
 	}
 
-	return false
+	return nil
 }
 
 // CheckCR analyzes POSIXy scripts for the presence/absence of a CR/CRLF line ending sequence.
-func CheckCR(smell stank.Smell) bool {
+func (o Funk) CheckCR(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
 	if smell.ContainsCR {
-		fmt.Printf("CR/CRLF line ending detected: %s\n", smell.Path)
-		return true
+		diagnostic := stank.NewDiagnostic(stank.RuleCR, smell.Path, "CR/CRLF line ending detected")
+
+		if index := bytes.IndexByte(analysis.Bytes, '\r'); index != -1 {
+			diagnostic.Line = lineOf(analysis, index)
+		}
+
+		diagnostic.Fix = "Convert line endings to LF"
+		return []stank.Diagnostic{diagnostic}
 	}
 
-	return false
+	return nil
 }
 
-// CheckBoms() analyzes POSIXy scripts for byte order markers. If a BOM is found, CheckBoms() prints a warning and returns true.
-// Otherwise, CheckBoms() returns false.
-func CheckBoms(smell stank.Smell) bool {
-	if smell.BOM {
-		fmt.Printf("Leading BOM reduces portability: %s\n", smell.Path)
+// CheckBoms() analyzes POSIXy scripts for byte order markers.
+func (o Funk) CheckBoms(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
 
-		return true
+	if smell.BOM {
+		diagnostic := stank.NewDiagnostic(stank.RuleBOM, smell.Path, "Leading BOM reduces portability")
+		diagnostic.Line = 1
+		diagnostic.Column = 1
+		diagnostic.Fix = "Remove the leading byte order marker"
+		return []stank.Diagnostic{diagnostic}
 	}
 
-	return false
+	return nil
 }
 
-// CheckShebangs analyzes POSIXy scripts for some shebang oddities. If an oddity is found, CheckShebangs prints a warning and returns true.
-// Otherwise, CheckShebangs returns false.
+// CheckShebangs analyzes POSIXy scripts for some shebang oddities.
 //
 // Note: While shell safety flags are risky when placed in shebangs,
 // Unfortunately many non-POSIXy languages unfortunately require such flags:
 // sed, awk, Emacs Lisp, Fourth, Octave, Mathematica, ...
 // Therefore, CheckShebangs may trigger unactionable warnings when run on non-POSIXy files.
-func (o Funk) CheckShebangs(smell stank.Smell) bool {
-	if stank.LowerExtensionsToConfig()[strings.ToLower(smell.Extension)] || o.sniffer.LowerFilenamesToConfig[strings.ToLower(smell.Filename)] {
-		return false
-	}
-
-	if smell.Shebang == "" {
-		fmt.Printf("Missing shebang: %s\n", smell.Path)
-		return true
-	}
+func (o Funk) CheckShebangs(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
 
-	if !strings.HasPrefix(smell.Shebang, "#!") {
-		fmt.Printf("Shebang appears to be flipped: %v\n", smell.Path)
-		return true
-	}
-
-	if !strings.HasPrefix(smell.Shebang, "#!/") {
-		fmt.Printf("Shebang application should be absolute and non-nested: %v\n", smell.Path)
-		return true
+	if smell.CoreConfiguration {
+		return nil
 	}
 
-	if strings.Contains(smell.Shebang[2:], "#") {
-		fmt.Printf("Commented shebangs may be unparsable: %v\n", smell.Path)
-		return true
-	}
+	diagnostic := stank.NewDiagnostic(stank.RuleShebang, smell.Path, "")
+	diagnostic.Line = 1
 
-	if len(smell.InterpreterFlags) != 0 {
-		fmt.Printf("Risk of parse error for interpreter space / secondary argument. Any safety flags will be ignored on `%v <script>` launch: %v\n", smell.Interpreter, smell.Path)
-		return true
+	switch {
+	case smell.Shebang == "":
+		diagnostic.Line = 0
+		diagnostic.Message = "Missing shebang"
+	case !strings.HasPrefix(smell.Shebang, "#!"):
+		diagnostic.Message = "Shebang appears to be flipped"
+		diagnostic.Fix = "Replace the leading !# with #!"
+	case !strings.HasPrefix(smell.Shebang, "#!/"):
+		diagnostic.Message = "Shebang application should be absolute and non-nested"
+	case strings.Contains(smell.Shebang[2:], "#"):
+		diagnostic.Message = "Commented shebangs may be unparsable"
+	case len(smell.InterpreterFlags) != 0:
+		diagnostic.Message = fmt.Sprintf("Risk of parse error for interpreter space / secondary argument. Any safety flags will be ignored on `%v <script>` launch", smell.Interpreter)
+		diagnostic.Fix = "Move interpreter flags to a set command"
+	default:
+		return nil
 	}
 
-	return false
+	return []stank.Diagnostic{diagnostic}
 }
 
-// CheckPermissions analyzes POSIXy scripts for some file permission oddities. If an oddity is found, CheckPermissions prints a warning and returns true.
-// Otherwise, CheckPermissions returns false.
-func CheckPermissions(smell stank.Smell) bool {
+// CheckPermissions analyzes POSIXy scripts for some file permission oddities.
+func (o Funk) CheckPermissions(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
 	if smell.Library && smell.Permissions&0111 != 0 {
-		fmt.Printf("Sourceable script features executable mode bits: %s\n", smell.Path)
-		return true
+		diagnostic := stank.NewDiagnostic(stank.RulePermissions, smell.Path, "Sourceable script features executable mode bits")
+		diagnostic.Fix = "chmod a-x"
+		return []stank.Diagnostic{diagnostic}
 	}
 
 	if (smell.Extension == "" && smell.Permissions&0100 == 0) ||
 		(smell.Extension != "" && smell.Permissions&0111 != 0) {
-		fmt.Printf("Ambiguous launch style. Either feature a file extensions, or else feature executable bits: %v\n", smell.Path)
-		return true
+		return []stank.Diagnostic{
+			stank.NewDiagnostic(stank.RulePermissions, smell.Path, "Ambiguous launch style. Either feature a file extensions, or else feature executable bits"),
+		}
+	}
+
+	return nil
+}
+
+// CheckInterpreterMismatch analyzes scripts for shebangs conflicting with the extension,
+// such as .sh scripts run by bash.
+func (o Funk) CheckInterpreterMismatch(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+	extensionInterpreter := o.Sniffer.MismatchedInterpreter(smell, o.InterpreterPairings)
+
+	if extensionInterpreter == "" {
+		return nil
 	}
 
-	return false
+	diagnostic := stank.NewDiagnostic(stank.RuleInterpreterMismatch, smell.Path, fmt.Sprintf("Interpreter mismatch between shebang (%v) and extension (%v)", smell.Interpreter, extensionInterpreter))
+	diagnostic.Line = 1
+	diagnostic.Fix = fmt.Sprintf("Rename the extension, or else declare a %v interpreter", extensionInterpreter)
+	return []stank.Diagnostic{diagnostic}
 }
 
 // CheckModulino warns when a smell features some aspects of an application, such as executable bits, and simultaneously some aspects of a library, such as a non-empty file extension.
-// If the file is a pure application or library, CheckModulino returns false.
-// Otherwise, CheckModulino returns true.
-func (o Funk) CheckModulino(smell stank.Smell) bool {
-	if stank.LowerExtensionsToConfig()[strings.ToLower(smell.Extension)] || o.sniffer.LowerFilenamesToConfig[strings.ToLower(smell.Filename)] {
-		return false
+func (o Funk) CheckModulino(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
+	if smell.CoreConfiguration {
+		return nil
 	}
 
 	if (smell.Extension == "" && !smell.OwnerExecutable) || (smell.Extension != "" && (smell.Permissions&0100 != 0 || smell.Permissions&0010 != 0 || smell.Permissions&0001 != 0)) {
-		fmt.Printf("Modulino ambiguity. Either have owner executable permissions with no extension, or else remove executable bits and use an extension like .lib.sh: %s\n", smell.Path)
-		return true
+		return []stank.Diagnostic{
+			stank.NewDiagnostic(stank.RuleModulino, smell.Path, "Modulino ambiguity. Either have owner executable permissions with no extension, or else remove executable bits and use an extension like .lib.sh"),
+		}
 	}
 
-	return false
+	return nil
 }
 
 // CheckSyntax validates script contents.
-func CheckSyntax(smell stank.Smell) bool {
-	if !smell.POSIXy {
-		return false
+//
+// Alternative shell scripts, such as fish and csh scripts, validate under the alt-syntax rule,
+// given an installed interpreter. Otherwise, they are skipped, as in slim CI images.
+func (o Funk) CheckSyntax(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
+	if !smell.POSIXy && !(smell.AltShellScript && o.Rules.IsEnabled(stank.RuleAltSyntax)) {
+		return nil
 	}
 
-	validator, ok := stank.Interpreter2SyntaxValidator()[smell.Interpreter]
+	if !smell.POSIXy {
+		if _, ok := stank.Interpreter2SyntaxValidator()[smell.Interpreter]; !ok {
+			return nil
+		}
 
-	if !ok {
-		fmt.Printf("Unknown validator for interpreter: %v\n", smell.Path)
-		return true
+		if _, err := exec.LookPath(smell.Interpreter); err != nil {
+			return nil
+		}
 	}
 
-	if smell.Interpreter != "generic-sh" && smell.Interpreter != "sh" {
+	if _, ok := stank.Interpreter2SyntaxValidator()[smell.Interpreter]; !ok {
+		return []stank.Diagnostic{
+			stank.NewDiagnostic(stank.RuleInterpreter, smell.Path, "Unknown validator for interpreter"),
+		}
+	}
+
+	// Shells with an in-process parser variant validate even when missing from PATH.
+	if _, ok := o.Sniffer.FallbackVariant(smell.Interpreter); !ok {
 		_, err := exec.LookPath(smell.Interpreter)
 
 		if err != nil {
-			fmt.Printf("Interpreter not found: %v\n", smell.Path)
-			return true
+			return []stank.Diagnostic{
+				stank.NewDiagnostic(stank.RuleInterpreter, smell.Path, "Interpreter not found"),
+			}
 		}
 	}
 
-	if err := validator(smell); err != nil {
-		fmt.Printf("%v syntax error: %v\n", smell.Interpreter, err)
-		return true
+	if err := analysis.CheckSyntax(); err != nil {
+		diagnostic := SyntaxDiagnostic(smell, err)
+
+		if !smell.POSIXy {
+			diagnostic.Rule = stank.RuleAltSyntax
+			diagnostic.Severity = stank.Rules()[stank.RuleAltSyntax].Severity
+		}
+
+		return []stank.Diagnostic{diagnostic}
 	}
 
-	return false
+	return nil
 }
 
-// UnsetIFSPattern matches unset IFS commands.
-var UnsetIFSPattern = regexp.MustCompile(`^(\s)*unset(\s)+IFS(\s+(#.*)?)?$`)
+// SyntaxDiagnostic converts a syntax validation error into a diagnostic,
+// locating parse errors where possible.
+//
+// Messages name the validation backend, when known.
+func SyntaxDiagnostic(smell stank.Smell, err error) stank.Diagnostic {
+	text := err.Error()
+	var backendErr stank.BackendError
 
-// CheckIFSReset enforces IFS configured to '\n\t ' near the beginning of executable scripts,
-// in order to reduce tokenization errors.
-func CheckIFSReset(smell stank.Smell) bool {
-	if !smell.POSIXy || smell.Library {
-		return false
+	if errors.As(err, &backendErr) {
+		text = backendErr.Err.Error()
 	}
 
-	fd, err := os.Open(smell.Path)
+	var pos syntax.Pos
+	var parseErr syntax.ParseError
+	var langErr syntax.LangError
+	var interpreterErr stank.InterpreterError
 
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+	switch {
+	case errors.As(err, &parseErr):
+		pos = parseErr.Pos
+		text = parseErr.Text
+	case errors.As(err, &langErr):
+		pos = langErr.Pos
+		text = strings.TrimPrefix(text, fmt.Sprintf("%v:%v: ", langErr.Filename, langErr.Pos))
+	case errors.As(err, &interpreterErr):
+		// Avoid stutters like bash syntax error: syntax error: ...
+		text = strings.TrimPrefix(strings.TrimPrefix(interpreterErr.Message, "syntax error: "), "Syntax error: ")
 	}
 
-	defer func() {
-		err = fd.Close()
+	message := fmt.Sprintf("%v syntax error: %v", smell.Interpreter, text)
 
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-		}
-	}()
+	if backendErr.Backend != "" {
+		message = fmt.Sprintf("%v syntax error (%v): %v", smell.Interpreter, backendErr.Backend, text)
+	}
 
-	fi, err := os.Lstat(smell.Path)
+	diagnostic := stank.NewDiagnostic(stank.RuleSyntax, smell.Path, message)
 
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+	if pos.IsValid() {
+		diagnostic.Line = int(pos.Line())
+		diagnostic.Column = int(pos.Col())
 	}
 
-	if fi.Size() == 0 {
-		return false
+	if interpreterErr.Line != 0 {
+		diagnostic.Line = interpreterErr.Line
+		diagnostic.Column = interpreterErr.Column
 	}
 
-	scanner := bufio.NewScanner(fd)
-
-	var candidateLine string
-
-	for scanner.Scan() {
-		line := scanner.Text()
+	return diagnostic
+}
 
-		if UnsetIFSPattern.MatchString(line) {
-			return false
-		}
+// stmtDiagnostic constructs a Diagnostic located at a statement.
+func stmtDiagnostic(rule string, pth string, message string, node syntax.Node) stank.Diagnostic {
+	diagnostic := stank.NewDiagnostic(rule, pth, message)
+	diagnostic.Line = int(node.Pos().Line())
+	diagnostic.Column = int(node.Pos().Col())
+	return diagnostic
+}
 
-		if strings.HasPrefix(line, "#") ||
-			strings.HasPrefix(line, "set") ||
-			strings.HasPrefix(line, "unset") ||
-			strings.TrimSpace(line) == "" {
-			continue
-		}
+// CheckIFSReset enforces IFS configured to '\n\t ' near the beginning of executable scripts,
+// in order to reduce tokenization errors.
+//
+// Top level statements are examined in order, skipping set and unset commands,
+// until the first IFS reset, or else the first other command.
+func (o Funk) CheckIFSReset(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
 
-		candidateLine = line
-		break
+	if !smell.POSIXy || smell.Library {
+		return nil
 	}
 
-	if candidateLine == "" {
-		return false
-	}
+	file, err := analysis.File()
 
-	if index := strings.Index(candidateLine, "#"); index != -1 {
-		candidateLine = candidateLine[:index]
+	if err != nil {
+		return nil
 	}
 
-	candidateLine = strings.TrimSpace(candidateLine)
-
-	assignmentParts := strings.Split(candidateLine, "=")
+	stmt := stank.MissingIFSReset(file)
 
-	if len(assignmentParts) < 1 || strings.TrimSpace(assignmentParts[0]) != "IFS" {
-		fmt.Printf("Tokenize like `unset IFS` at the top of executable scripts: %v\n", smell.Path)
-		return true
+	if stmt == nil {
+		return nil
 	}
 
-	return false
+	diagnostic := stmtDiagnostic(stank.RuleIFSReset, smell.Path, "Tokenize like `unset IFS` at the top of executable scripts", stmt)
+	diagnostic.Fix = "Insert `unset IFS` before the first command"
+	return []stank.Diagnostic{diagnostic}
 }
 
 // CheckSafetyFlags warns on missing `set`... safety command from the beginning of executable scripts,
 // in order to reduce runtime errors.
-func CheckSafetyFlags(smell stank.Smell) bool {
+//
+// Top level statements are examined in order, skipping IFS resets and unset commands,
+// until the first set command, or else the first other command.
+func (o Funk) CheckSafetyFlags(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
 	if !smell.POSIXy || smell.Library {
-		return false
+		return nil
 	}
 
-	fd, err := os.Open(smell.Path)
+	file, err := analysis.File()
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+		return nil
 	}
 
-	defer func() {
-		err = fd.Close()
+	stmt := stank.MissingSafetyFlags(file)
 
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-		}
-	}()
+	if stmt == nil {
+		return nil
+	}
+
+	diagnostic := stmtDiagnostic(stank.RuleSafetyFlags, smell.Path, "Control program flow like `set -euf` at the top of executable scripts", stmt)
+	diagnostic.Fix = "Insert `set -euf` before the first command"
+	return []stank.Diagnostic{diagnostic}
+}
 
-	fi, err := os.Lstat(smell.Path)
+// CheckBashisms flags bash extensions in scripts declared as POSIX sh,
+// like a built-in checkbashisms.
+func (o Funk) CheckBashisms(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
+	if !smell.POSIXy || !stank.POSIXShInterpreters()[smell.Interpreter] {
+		return nil
+	}
+
+	bashisms, err := analysis.Bashisms()
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+		return nil
 	}
 
-	if fi.Size() == 0 {
-		return false
+	var diagnostics []stank.Diagnostic
+
+	for _, bashism := range bashisms {
+		diagnostic := stank.NewDiagnostic(stank.RuleBashisms, smell.Path, fmt.Sprintf("Bashism %v is not POSIX", bashism.Construct))
+		diagnostic.Line = bashism.Line
+		diagnostic.Column = bashism.Column
+		diagnostic.Fix = bashism.Replacement
+		diagnostics = append(diagnostics, diagnostic)
 	}
 
-	scanner := bufio.NewScanner(fd)
+	return diagnostics
+}
 
-	var candidateLine string
+// CheckDialect compares the interpreter against the minimal dialect which parses the script.
+//
+// As mksh and bash are siblings rather than strict supersets, shebangs requesting more than
+// the script needs are only flagged for pure POSIX scripts, free of any bashisms.
+func (o Funk) CheckDialect(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+	if !smell.POSIXy {
+		return nil
+	}
 
-		if strings.HasPrefix(line, "#") ||
-			strings.HasPrefix(line, "IFS") ||
-			strings.HasPrefix(line, "unset") ||
-			strings.TrimSpace(line) == "" {
-			continue
-		}
+	declared := o.Sniffer.DeclaredDialect(smell)
 
-		candidateLine = line
-		break
+	if declared == "" {
+		return nil
 	}
 
-	if candidateLine == "" {
-		return false
+	smell.Dialect = analysis.Dialect()
+
+	if smell.Dialect == "" {
+		return nil
 	}
 
-	if index := strings.Index(candidateLine, "#"); index != -1 {
-		candidateLine = candidateLine[:index]
+	switch rank := stank.DialectRank(smell.Dialect); {
+	case rank > stank.DialectRank(declared):
+		diagnostic := stank.NewDiagnostic(stank.RuleDialect, smell.Path, fmt.Sprintf("Interpreter %v requests %v, though the script requires %v", smell.Interpreter, declared, smell.Dialect))
+		diagnostic.Fix = fmt.Sprintf("Declare a %v interpreter", smell.Dialect)
+		return []stank.Diagnostic{diagnostic}
+	case rank < stank.DialectRank(declared) && smell.Dialect == "posix" && smell.Shebang != "" && !smell.CoreConfiguration:
+		if bashisms, err := analysis.Bashisms(); err != nil || len(bashisms) != 0 {
+			return nil
+		}
+
+		diagnostic := stank.NewDiagnostic(stank.RuleDialect, smell.Path, fmt.Sprintf("Shebang requests %v, though the script parses as posix", declared))
+		diagnostic.Fix = "Declare a sh interpreter, for portability"
+		return []stank.Diagnostic{diagnostic}
+	default:
+		return nil
 	}
+}
 
-	candidateLine = strings.TrimSpace(candidateLine)
+// CheckTrapHazards warns when traps risk colliding with other control flow semantics.
+func (o Funk) CheckTrapHazards(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
 
-	parts := strings.Split(candidateLine, " ")
+	if !smell.POSIXy {
+		return nil
+	}
 
-	if len(parts) < 1 || strings.TrimSpace(parts[0]) != "set" {
-		fmt.Printf("Control program flow like `set -euf` at the top of executable scripts: %v\n", smell.Path)
-		return true
+	file, err := analysis.File()
+
+	if err != nil {
+		return nil
 	}
 
-	return false
-}
+	// First occurrences, or nil.
+	var listTrap syntax.Node
+	var trap syntax.Node
+	var exec syntax.Node
+	var hasErrtraceFlag bool
 
-// ListTrapPattern matches POSIX trap declarations.
-var ListTrapPattern = regexp.MustCompile("^trap +.+$")
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			if node.Name != nil && strings.HasPrefix(node.Name.Value, "TRAP") && trap == nil {
+				trap = node
+			}
+		case *syntax.CallExpr:
+			if len(node.Args) == 0 {
+				return true
+			}
 
-// FunctionTrapPattern matches zsh function trap declarations.
-var FunctionTrapPattern = regexp.MustCompile(`^TRAP.+\(\).+$`)
+			args := make([]string, len(node.Args))
 
-// ExecPattern matches POSIX exec commands.
-var ExecPattern = regexp.MustCompile("^exec .+$")
+			for i, arg := range node.Args {
+				args[i] = arg.Lit()
+			}
 
-// SetPattern matches set flags
-var SetPattern = regexp.MustCompile("^set (?P<Flags>.+)$")
+			switch args[0] {
+			case "trap":
+				if listTrap == nil {
+					listTrap = node
+				}
+
+				if trap == nil {
+					trap = node
+				}
+			case "exec":
+				// Redirection-only exec's preserve the shell process, and its traps.
+				if len(args) > 1 && exec == nil {
+					exec = node
+				}
+			case "set":
+				if stank.HasErrTraceFlag(args[1:]) {
+					hasErrtraceFlag = true
+				}
+			}
+		}
 
-// ErrTraceFlagPattern matches GNU bash -E or -o errtrace flags.
-var ErrTraceFlagPattern = regexp.MustCompile(`^(-[^\s]*E)|-[^\s]*o errtrace$`)
+		return true
+	})
 
-// CheckTrapHazards warns when traps risk colliding with other control flow semantics.
-func CheckTrapHazards(smell stank.Smell) bool {
-	if !smell.POSIXy {
-		return false
+	if trap == nil {
+		return nil
 	}
 
-	fd, err := os.Open(smell.Path)
+	var diagnostics []stank.Diagnostic
 
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+	if exec != nil {
+		diagnostics = append(diagnostics, stmtDiagnostic(stank.RuleTrapHazards, smell.Path, "exec discards traps", exec))
 	}
 
-	defer func() {
-		err = fd.Close()
-
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
+	switch {
+	case smell.Interpreter == "zsh":
+		if listTrap != nil {
+			diagnostics = append(diagnostics, stmtDiagnostic(stank.RuleTrapHazards, smell.Path, "List traps deprecated in favor of function traps", listTrap))
+		}
+	case strings.HasPrefix(smell.Interpreter, "bash"):
+		if !hasErrtraceFlag {
+			diagnostic := stmtDiagnostic(stank.RuleTrapHazards, smell.Path, "Missing `set -E` / `set -o errtrace` to guard traps", trap)
+			diagnostic.Fix = "Insert `set -E` before the first trap"
+			diagnostics = append(diagnostics, diagnostic)
 		}
-	}()
+	default:
+		diagnostics = append(diagnostics, stmtDiagnostic(stank.RuleTrapHazards, smell.Path, "Traps may reset in subshells", trap))
+	}
 
-	fi, err := os.Lstat(smell.Path)
+	return diagnostics
+}
 
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return true
+// CheckFormat analyzes POSIXy scripts for formatting differing from the canonical print,
+// parsed in the language variant of the interpreter.
+//
+// Scripts which fail to parse are left to the syntax rule.
+func (o Funk) CheckFormat(analysis stank.Analysis) []stank.Diagnostic {
+	smell := analysis.Smell
+
+	if !smell.POSIXy {
+		return nil
 	}
 
-	if fi.Size() == 0 {
-		return false
+	formatted, err := analysis.Format(o.FormatOptions)
+
+	if err != nil || bytes.Equal(formatted, analysis.Bytes) {
+		return nil
 	}
 
-	scanner := bufio.NewScanner(fd)
+	diagnostic := stank.NewDiagnostic(stank.RuleFormat, smell.Path, "Formatting differs from the canonical print")
+	diagnostic.Line = stank.FirstDifferingLine(analysis.Bytes, formatted)
 
-	var hasListTrap bool
-	var hasTrap bool
-	var hasExec bool
-	var hasErrtraceFlag bool
+	// Differences confined to line endings land on the final line.
+	if diagnostic.Line == 0 {
+		diagnostic.Line = len(analysis.Lines)
+	}
+
+	diagnostic.Fix = "Reprint the script canonically, as with funk -fmt -fix"
+	return []stank.Diagnostic{diagnostic}
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// CheckUnusedSuppressions warns on funk:disable directives which suppressed nothing.
+//
+// Directives naming disabled rules are left alone, as other configurations may need them.
+func (o Funk) CheckUnusedSuppressions(analysis stank.Analysis, suppressions []stank.Suppression, used []bool) []stank.Diagnostic {
+	var diagnostics []stank.Diagnostic
 
-		if strings.HasPrefix(line, "#") {
+	for i, suppression := range suppressions {
+		if used[i] || suppression.Rule == stank.RuleUnusedSuppression {
 			continue
 		}
 
-		line = strings.TrimSpace(line)
+		var message string
 
-		if line == "" {
+		switch _, ok := stank.Rules()[suppression.Rule]; {
+		case suppression.Rule == "all":
+			message = "Unused suppression of all rules"
+		case !ok:
+			message = fmt.Sprintf("Suppression names unknown rule %v", suppression.Rule)
+		case !o.Rules.IsEnabled(suppression.Rule):
 			continue
+		default:
+			message = fmt.Sprintf("Unused suppression of %v", suppression.Rule)
 		}
 
-		if ListTrapPattern.MatchString(line) {
-			hasListTrap = true
-			hasTrap = true
-		}
+		diagnostic := stank.NewDiagnostic(stank.RuleUnusedSuppression, analysis.Smell.Path, message)
+		diagnostic.Line = suppression.Line
+		diagnostic.Fix = "Remove the stale directive"
+		diagnostics = append(diagnostics, diagnostic)
+	}
 
-		if FunctionTrapPattern.MatchString(line) {
-			hasTrap = true
-		}
+	return diagnostics
+}
 
-		if ExecPattern.MatchString(line) {
-			hasExec = true
-		}
+// filter applies rule selection and suppressions, marking any used suppressions.
+func (o Funk) filter(diagnostics []stank.Diagnostic, suppressions []stank.Suppression, used []bool) []stank.Diagnostic {
+	return stank.Suppress(o.Rules.Apply(diagnostics), suppressions, used)
+}
 
-		if SetPattern.MatchString(line) {
-			m := SetPattern.FindStringSubmatch(line)
-			flags := m[SetPattern.SubexpIndex("Flags")]
+// FunkyCheck analyzes POSIXy scripts for some oddities, collecting any diagnostics.
+//
+// Diagnostics covered by funk:disable directives are dropped.
+func (o Funk) FunkyCheck(analysis stank.Analysis) []stank.Diagnostic {
+	var diagnostics []stank.Diagnostic
+	suppressions := stank.ParseSuppressions(analysis.Lines)
+	used := make([]bool, len(suppressions))
+
+	if o.Rules.IsEnabled(stank.RuleEOL) {
+		diagnostics = append(diagnostics, o.CheckEOL(analysis)...)
+	}
 
-			if ErrTraceFlagPattern.MatchString(flags) {
-				hasErrtraceFlag = true
-			}
-		}
+	if o.Rules.IsEnabled(stank.RuleCR) {
+		diagnostics = append(diagnostics, o.CheckCR(analysis)...)
 	}
 
-	if !hasTrap {
-		return false
+	if o.Rules.IsEnabled(stank.RuleModulino) {
+		diagnostics = append(diagnostics, o.CheckModulino(analysis)...)
 	}
 
-	var hasWarning bool
+	if o.Rules.IsEnabled(stank.RuleBOM) {
+		diagnostics = append(diagnostics, o.CheckBoms(analysis)...)
+	}
 
-	if hasExec {
-		fmt.Printf("exec discards traps: %v\n", smell.Path)
-		hasWarning = true
+	if o.Rules.IsEnabled(stank.RuleShebang) {
+		diagnostics = append(diagnostics, o.CheckShebangs(analysis)...)
 	}
 
-	switch {
-	case smell.Interpreter == "zsh":
-		if hasListTrap {
-			fmt.Printf("List traps deprecated in favor of function traps: %v\n", smell.Path)
-			hasWarning = true
-		}
-	case strings.HasPrefix(smell.Interpreter, "bash"):
-		if !hasErrtraceFlag {
-			fmt.Printf("Missing `set -E` / `set -o errtrace` to guard traps")
-			hasWarning = true
-		}
-	default:
-		fmt.Printf("Traps may reset in subshells: %v\n", smell.Path)
-		hasWarning = true
+	if o.Rules.IsEnabled(stank.RulePermissions) {
+		diagnostics = append(diagnostics, o.CheckPermissions(analysis)...)
 	}
 
-	return hasWarning
-}
+	if o.Rules.IsEnabled(stank.RuleInterpreterMismatch) {
+		diagnostics = append(diagnostics, o.CheckInterpreterMismatch(analysis)...)
+	}
 
-// FunkyCheck analyzes POSIXy scripts for some oddities. If an oddity is found, FunkyCheck prints a warning and returns true.
-// Otherwise, FunkyCheck returns false.
-func (o Funk) FunkyCheck(smell stank.Smell) bool {
-	var resEOL bool
+	// Bashisms commonly break POSIX parsing, so check them ahead of syntax.
+	if o.Rules.IsEnabled(stank.RuleBashisms) {
+		diagnostics = append(diagnostics, o.CheckBashisms(analysis)...)
+	}
 
-	if o.EOLCheck {
-		resEOL = CheckEOL(smell)
+	if o.Rules.IsEnabled(stank.RuleDialect) {
+		diagnostics = append(diagnostics, o.CheckDialect(analysis)...)
 	}
 
-	var resCR bool
+	if o.Rules.IsEnabled(stank.RuleSyntax) || o.Rules.IsEnabled(stank.RuleAltSyntax) || o.Rules.IsEnabled(stank.RuleInterpreter) {
+		syntaxDiagnostics := o.filter(o.CheckSyntax(analysis), suppressions, used)
 
-	if o.CRCheck {
-		resCR = CheckCR(smell)
+		// Skip the remaining checks, and any unused suppression warnings they would otherwise clear.
+		if len(syntaxDiagnostics) != 0 {
+			return append(o.filter(diagnostics, suppressions, used), syntaxDiagnostics...)
+		}
 	}
 
-	var resModulino bool
+	if o.Rules.IsEnabled(stank.RuleIFSReset) {
+		diagnostics = append(diagnostics, o.CheckIFSReset(analysis)...)
+	}
 
-	if o.ModulinoCheck {
-		resModulino = o.CheckModulino(smell)
+	if o.Rules.IsEnabled(stank.RuleSafetyFlags) {
+		diagnostics = append(diagnostics, o.CheckSafetyFlags(analysis)...)
 	}
 
-	resBOM := CheckBoms(smell)
-	resShebang := o.CheckShebangs(smell)
-	resPerms := CheckPermissions(smell)
-	resSyntax := CheckSyntax(smell)
+	if o.Rules.IsEnabled(stank.RuleTrapHazards) {
+		diagnostics = append(diagnostics, o.CheckTrapHazards(analysis)...)
+	}
 
-	if resSyntax {
-		return true
+	if o.Rules.IsEnabled(stank.RuleFormat) {
+		diagnostics = append(diagnostics, o.CheckFormat(analysis)...)
 	}
 
-	resIFSReset := CheckIFSReset(smell)
-	resSafetyFlags := CheckSafetyFlags(smell)
-	resTrapHazards := CheckTrapHazards(smell)
+	diagnostics = o.filter(diagnostics, suppressions, used)
 
-	return resEOL ||
-		resCR ||
-		resBOM ||
-		resModulino ||
-		resShebang ||
-		resPerms ||
-		resIFSReset ||
-		resSafetyFlags ||
-		resTrapHazards
+	if o.Rules.IsEnabled(stank.RuleUnusedSuppression) {
+		diagnostics = append(diagnostics, o.filter(o.CheckUnusedSuppressions(analysis, suppressions, used), suppressions, used)...)
+	}
+
+	return diagnostics
 }
 
-// Walk is a callback for filepath.Walk to lint shell scripts.
-func (o *Funk) Walk(pth string, _ os.FileInfo, _ error) error {
-	if stank.Ignore(pth) {
+// Lint checks a shell script, collecting any diagnostics.
+//
+// Lint leaves FoundOdor untouched, so that concurrent lints may share the same Funk.
+func (o Funk) Lint(analysis stank.Analysis) []stank.Diagnostic {
+	if !analysis.Smell.POSIXy && !analysis.Smell.AltShellScript {
 		return nil
 	}
 
-	smell, err2 := o.sniffer.Sniff(pth, stank.SniffConfig{EOLCheck: o.EOLCheck, CRCheck: o.CRCheck})
+	diagnostics := o.FunkyCheck(analysis)
 
-	if err2 != nil && err2 != io.EOF {
-		fmt.Printf("%v\n", err2)
+	for i, diagnostic := range diagnostics {
+		diagnostics[i].Fingerprint = analysis.Fingerprint(diagnostic)
 	}
 
-	if smell.MachineGenerated {
+	return diagnostics
+}
+
+// NewFix prepares rewrites of a script to resolve any fixable diagnostics,
+// returning the unresolved diagnostics.
+func (o Funk) NewFix(analysis stank.Analysis, diagnostics []stank.Diagnostic) (*stank.Fix, []stank.Diagnostic) {
+	fix := o.Sniffer.NewFix(analysis)
+	fix.ShebangPrefix = o.ShebangPrefix
+	fix.FormatOptions = o.FormatOptions
+	return fix, fix.Apply(diagnostics)
+}
+
+// Observe handles a diagnostic, either recording it to NewBaseline,
+// skipping a known Baseline finding, or else reporting it.
+//
+// Warnings and errors flag FoundOdor; informational findings do not.
+func (o *Funk) Observe(diagnostic stank.Diagnostic) error {
+	if o.NewBaseline != nil {
+		o.NewBaseline.Add(diagnostic)
 		return nil
 	}
 
-	if (smell.POSIXy || smell.AltShellScript) && o.FunkyCheck(smell) {
+	if o.Baseline[diagnostic.Fingerprint] > 0 {
+		o.Baseline[diagnostic.Fingerprint]--
+		return nil
+	}
+
+	if diagnostic.Severity >= stank.SeverityWarning {
 		o.FoundOdor = true
 	}
 
-	return nil
+	return o.Reporter.Report(diagnostic)
+}
+
+// Configure selects the rules, classification tables, and fix preferences
+// governing a file, applying its project configuration files outermost first, followed by RuleConfig.
+func (o *Funk) Configure(configs []stank.Config) error {
+	o.Rules = stank.NewRuleSet()
+	o.ShebangPrefix = stank.DefaultShebangPrefix
+	o.InterpreterPairings = map[string][]string{}
+	o.FormatOptions = stank.FormatOptions{}
+	o.Sniffer = o.WalkConfig.Sniffer
+	var funkConfigs []stank.FunkConfig
+
+	for _, config := range configs {
+		if err := o.Rules.Configure(config.Funk); err != nil {
+			return fmt.Errorf("%v: %v", config.Path, err)
+		}
+
+		o.Sniffer = config.Overlay(o.Sniffer)
+		funkConfigs = append(funkConfigs, config.Funk)
+	}
+
+	for _, config := range append(funkConfigs, o.RuleConfig) {
+		if config.ShebangPrefix != "" {
+			o.ShebangPrefix = config.ShebangPrefix
+		}
+
+		if config.Format != (stank.FormatOptions{}) {
+			o.FormatOptions = config.Format
+		}
+
+		for extension, interpreters := range config.InterpreterPairings {
+			o.InterpreterPairings[strings.ToLower(extension)] = interpreters
+		}
+	}
+
+	return o.Rules.Configure(o.RuleConfig)
+}
+
+// Matrix checks each POSIXy script beneath the roots against each of the given shells.
+func (o Funk) Matrix(roots []string, shells []string) ([]stank.MatrixRow, error) {
+	type check struct {
+		row *stank.MatrixRow
+		err error
+	}
+
+	var rows []stank.MatrixRow
+
+	checks := stank.ParallelMap(o.Jobs, stank.WalkSmells(o.WalkConfig, roots...), func(smell stank.Smell, err error) check {
+		if err != nil {
+			return check{err: err}
+		}
+
+		if !smell.POSIXy {
+			return check{}
+		}
+
+		row := stank.SyntaxMatrix(smell, shells)
+		return check{row: &row}
+	})
+
+	for c := range checks {
+		if c.err != nil {
+			return rows, c.err
+		}
+
+		if c.row != nil {
+			rows = append(rows, *c.row)
+		}
+	}
+
+	return rows, nil
+}
+
+// ListRules prints the rule catalog.
+func ListRules() {
+	rules := stank.Rules()
+
+	for _, id := range slices.Sorted(maps.Keys(rules)) {
+		fmt.Printf("%v\t%v\t%v\n", id, rules[id].Severity, rules[id].Description)
+	}
 }
 
 func main() {
 	flag.Parse()
 
 	funk := NewFunk()
+	funk.RuleConfig.Profile = *flagProfile
+	funk.RuleConfig.Enable = stank.ParseRuleList(*flagEnable)
+	funk.RuleConfig.Disable = stank.ParseRuleList(*flagDisable)
+	funk.RuleConfig.ShebangPrefix = *flagShebangPrefix
 
-	if *flagEOL {
-		funk.EOLCheck = true
-	}
+	severities, err := stank.ParseSeverities(*flagSeverity)
 
-	if *flagCR {
-		funk.CRCheck = true
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if *flagModulino {
-		funk.ModulinoCheck = true
+	funk.RuleConfig.Severity = severities
+
+	// Legacy toggles apply only when explicitly set.
+	flag.Visit(func(f *flag.Flag) {
+		toggles := map[string]struct {
+			rule    string
+			enabled bool
+		}{
+			"eol":                        {stank.RuleEOL, *flagEOL},
+			"cr":                         {stank.RuleCR, *flagCR},
+			"modulino":                   {stank.RuleModulino, *flagModulino},
+			"alt-syntax":                 {stank.RuleAltSyntax, *flagAltSyntax},
+			"fmt":                        {stank.RuleFormat, *flagFmt},
+			"report-unused-suppressions": {stank.RuleUnusedSuppression, *flagReportUnusedSuppressions},
+		}
+
+		toggle, ok := toggles[f.Name]
+
+		if !ok {
+			return
+		}
+
+		if toggle.enabled {
+			funk.RuleConfig.Enable = append(funk.RuleConfig.Enable, toggle.rule)
+		} else {
+			funk.RuleConfig.Disable = append(funk.RuleConfig.Disable, toggle.rule)
+		}
+	})
+
+	reporter, err := stank.NewReporter(*flagFormat, os.Stdout)
+
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	funk.Reporter = reporter
+
+	funk.Diff = *flagDiff
+	funk.Fix = *flagFix
+	funk.Jobs = *flagJobs
+	funk.WalkConfig.GitIgnore = *flagGitIgnore
+	stank.SyntaxValidatorTimeout = *flagSyntaxTimeout
+
 	switch {
 	case *flagVersion:
 		fmt.Println(stank.Version)
@@ -543,12 +909,128 @@ func main() {
 	case *flagHelp:
 		flag.PrintDefaults()
 		os.Exit(0)
+	case *flagListRules:
+		ListRules()
+		os.Exit(0)
+	}
+
+	if *flagBaseline != "" {
+		baseline, err2 := stank.LoadBaseline(*flagBaseline)
+
+		if err2 != nil {
+			log.Fatal(err2)
+		}
+
+		funk.Baseline = baseline.Counts()
+	}
+
+	if *flagWriteBaseline != "" {
+		baseline := stank.NewBaseline()
+		funk.NewBaseline = &baseline
 	}
 
 	paths := flag.Args()
 
-	for _, pth := range paths {
-		filepath.Walk(pth, funk.Walk)
+	if *flagMatrix != "" {
+		shells := stank.ParseRuleList(*flagMatrix)
+		rows, err := funk.Matrix(paths, shells)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		switch *flagMatrixFormat {
+		case "table":
+			err = stank.WriteMatrixTable(os.Stdout, shells, rows)
+		case "json":
+			err = stank.WriteMatrixJSON(os.Stdout, rows)
+		default:
+			log.Fatalf("unknown matrix format: %v", *flagMatrixFormat)
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if slices.ContainsFunc(rows, stank.MatrixRow.Rejected) {
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	type lint struct {
+		diagnostics []stank.Diagnostic
+		diff        string
+		err         error
+	}
+
+	// Validate the command line rule selection up front, rather than per file.
+	if err := funk.Configure(nil); err != nil {
+		log.Fatal(err)
+	}
+
+	// Rules vary by directory, so sniff for every rule, and let each file's rules decide.
+	funk.WalkConfig.SniffConfig = stank.SniffConfig{EOLCheck: true, CRCheck: true}
+
+	lints := stank.ParallelMap(funk.Jobs, stank.WalkAnalyses(funk.WalkConfig, paths...), func(analysis stank.Analysis, err error) lint {
+		if err != nil {
+			return lint{err: err}
+		}
+
+		// Workers lint with a copy, leaving FoundOdor to this goroutine.
+		linter := funk
+
+		if err2 := linter.Configure(analysis.Configs); err2 != nil {
+			return lint{err: err2}
+		}
+
+		diagnostics := linter.Lint(analysis)
+
+		if !linter.Fix && !linter.Diff {
+			return lint{diagnostics: diagnostics}
+		}
+
+		fix, unresolved := linter.NewFix(analysis, diagnostics)
+
+		if linter.Diff {
+			return lint{diff: fix.Diff()}
+		}
+
+		if err2 := fix.Write(); err2 != nil {
+			return lint{diagnostics: diagnostics, err: err2}
+		}
+
+		return lint{diagnostics: unresolved}
+	})
+
+	for l := range lints {
+		// Failed fixes still carry their diagnostics.
+		if l.err != nil {
+			log.Print(l.err)
+			funk.FoundOdor = true
+		}
+
+		if l.diff != "" {
+			fmt.Print(l.diff)
+			funk.FoundOdor = true
+		}
+
+		for _, diagnostic := range l.diagnostics {
+			if err := funk.Observe(diagnostic); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	if funk.NewBaseline != nil {
+		if err := stank.WriteBaseline(*flagWriteBaseline, *funk.NewBaseline); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := funk.Reporter.Close(); err != nil {
+		log.Fatal(err)
 	}
 
 	if funk.FoundOdor {
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestCheckTrapHazards(t *testing.T) {
+	funk := NewFunk()
+	sniffer := funk.WalkConfig.Sniffer
+
+	for _, tc := range []struct {
+		name        string
+		interpreter string
+		contents    string
+		expected    []string
+	}{
+		{"heredoc", "sh", "#!/bin/sh\ncat <<EOF\ntrap 'rm -f tmp' EXIT\nexec foo\nEOF\n", nil},
+		{"quoted string", "sh", "#!/bin/sh\necho \"trap 'rm -f tmp' EXIT; exec foo\"\n", nil},
+		{"line continuation", "sh", "#!/bin/sh\ntrap \\\n  'rm -f tmp' EXIT\nexec \\\n  foo\n", []string{"4:1 exec discards traps", "2:1 Traps may reset in subshells"}},
+		{"redirection exec", "sh", "#!/bin/sh\ntrap 'rm -f tmp' EXIT\nexec >log\n", []string{"2:1 Traps may reset in subshells"}},
+		{"errtrace continuation", "bash", "#!/bin/bash\nset \\\n  -E\ntrap 'echo oops' ERR\n", nil},
+		{"errtrace heredoc", "bash", "#!/bin/bash\ncat <<EOF\nset -E\nEOF\n  trap 'echo oops' ERR\n", []string{"5:3 Missing `set -E` / `set -o errtrace` to guard traps"}},
+		{"zsh list trap", "zsh", "#!/bin/zsh\nif true; then trap 'rm -f tmp' EXIT; fi\n", []string{"2:15 List traps deprecated in favor of function traps"}},
+		{"zsh function trap", "zsh", "#!/bin/zsh\nTRAPEXIT() {\n  rm -f tmp\n}\n", nil},
+	} {
+		analysis := sniffer.NewAnalysis(stank.Smell{Path: "hazard", POSIXy: true, Interpreter: tc.interpreter}, []byte(tc.contents))
+		var observed []string
+
+		for _, diagnostic := range funk.CheckTrapHazards(analysis) {
+			observed = append(observed, fmt.Sprintf("%v:%v %v", diagnostic.Line, diagnostic.Column, diagnostic.Message))
+		}
+
+		if !slices.Equal(observed, tc.expected) {
+			t.Errorf("%v: expected %q, got %q", tc.name, tc.expected, observed)
+		}
+	}
+}
+
+// BenchmarkLintExamples lints the example scripts, as a serial funk run would.
+func BenchmarkLintExamples(b *testing.B) {
+	funk := NewFunk()
+	funk.WalkConfig.SniffConfig = stank.SniffConfig{EOLCheck: true, CRCheck: true}
+	root := filepath.Join("..", "..", "examples")
+
+	for b.Loop() {
+		for analysis, err := range stank.WalkAnalyses(funk.WalkConfig, root) {
+			if err != nil {
+				continue
+			}
+
+			funk.Lint(analysis)
+		}
+	}
+}
+
+func TestConfigureOverlaysNestedConfigs(t *testing.T) {
+	funk := NewFunk()
+	funk.RuleConfig.Disable = []string{stank.RuleTrapHazards}
+
+	configs := []stank.Config{
+		{Path: "a/.stank.toml", Funk: stank.FunkConfig{Disable: []string{stank.RuleSafetyFlags}, ShebangPrefix: "/usr/bin/"}},
+		{Path: "a/b/.stank.toml", Funk: stank.FunkConfig{Enable: []string{stank.RuleSafetyFlags, stank.RuleTrapHazards}}, LowerExtensionsToPosixyness: map[string]bool{".shlib": true}},
+	}
+
+	if err := funk.Configure(configs[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if funk.Rules.IsEnabled(stank.RuleSafetyFlags) || funk.ShebangPrefix != "/usr/bin/" {
+		t.Errorf("expected outer config to disable safety flags and set the shebang prefix")
+	}
+
+	if err := funk.Configure(configs); err != nil {
+		t.Fatal(err)
+	}
+
+	if !funk.Rules.IsEnabled(stank.RuleSafetyFlags) || funk.ShebangPrefix != "/usr/bin/" || !funk.Sniffer.LowerExtensionsToPosixyness[".shlib"] {
+		t.Errorf("expected nested config to overlay the outer config")
+	}
+
+	if stank.NewSniffer().LowerExtensionsToPosixyness[".shlib"] || funk.WalkConfig.Sniffer.LowerExtensionsToPosixyness[".shlib"] {
+		t.Errorf("expected base tables to remain unmodified")
+	}
+
+	if funk.Rules.IsEnabled(stank.RuleTrapHazards) {
+		t.Errorf("expected command line rules to take precedence over project configuration")
+	}
+
+	configs[1].Funk.Enable = []string{"bogus"}
+
+	if err := funk.Configure(configs); err == nil {
+		t.Errorf("expected unknown rule error")
+	}
+}
+
+func TestObserveInfoFindingsPass(t *testing.T) {
+	funk := NewFunk()
+	funk.Reporter = stank.NewTextReporter(io.Discard)
+	funk.RuleConfig.Severity = map[string]stank.Severity{stank.RuleBOM: stank.SeverityInfo}
+
+	if err := funk.Configure(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := funk.Sniffer.NewAnalysis(stank.Smell{Path: "bom.sh", POSIXy: true, BOM: true}, []byte("\xef\xbb\xbf#!/bin/sh\n"))
+	diagnostics := funk.FunkyCheck(analysis)
+
+	if !slices.ContainsFunc(diagnostics, func(diagnostic stank.Diagnostic) bool { return diagnostic.Rule == stank.RuleBOM }) {
+		t.Fatalf("expected a bom finding, got %v", diagnostics)
+	}
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Rule != stank.RuleBOM {
+			continue
+		}
+
+		if err := funk.Observe(diagnostic); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if funk.FoundOdor {
+		t.Errorf("expected info findings to leave FoundOdor unset")
+	}
+
+	if err := funk.Observe(stank.NewDiagnostic(stank.RuleShebang, "bom.sh", "Missing shebang")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !funk.FoundOdor {
+		t.Errorf("expected warnings to set FoundOdor")
+	}
+}
@@ -8,10 +8,9 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/mcandre/stank"
@@ -21,6 +20,8 @@ var flagSh = flag.Bool("sh", false, "Limit results to specifically bare POSIX sh
 var flagAlt = flag.Bool("alt", false, "Limit results to specifically alternative, non-POSIX lowlevel shell scripts")
 var flagExcludeInterpreters = flag.String("exInterp", "", "Remove results with the given interpreter(s) (Comma separated)")
 var flagPrint0 = flag.Bool("print0", false, "Delimit file path results with a null terminator for conjunction with xargs -0")
+var flagGitIgnore = flag.Bool("gitignore", true, "Skip paths excluded by .gitignore files")
+var flagJobs = flag.Int("jobs", runtime.NumCPU(), "Sniff up to this many files concurrently")
 var flagHelp = flag.Bool("help", false, "Show usage information")
 var flagVersion = flag.Bool("version", false, "Show version information")
 
@@ -49,15 +50,15 @@ type Stanker struct {
 	// Printer writes file path results.
 	Printer func(string)
 
-	// sniffer analyzes files.
-	sniffer stank.Sniffer
+	// WalkConfig controls file tree traversal.
+	WalkConfig stank.WalkConfig
 }
 
 // NewStanker constructs a Stanker.
 func NewStanker() Stanker {
 	var stanker Stanker
 	stanker.Mode = ModePOSIXy
-	stanker.sniffer = stank.NewSniffer()
+	stanker.WalkConfig = stank.NewWalkConfig()
 	return stanker
 }
 
@@ -72,27 +73,13 @@ func NullWriter(pth string) {
 	os.Stdout.Write([]byte{0x00})
 }
 
-// Walk sniffs a file system node for POSIXyness.
+// Stank reviews a smell for POSIXyness.
 // If the file smells sufficiently POSIXy, the path is printed.
 // Otherwise, the path is omitted.
-func (o Stanker) Walk(pth string, _ os.FileInfo, _ error) error {
-	smell, err2 := o.sniffer.Sniff(pth, stank.SniffConfig{})
-
-	if err2 != nil && err2 != io.EOF {
-		log.Print(err2)
-	}
-
-	if stank.Ignore(pth) {
-		return nil
-	}
-
-	if smell.MachineGenerated {
-		return nil
-	}
-
+func (o Stanker) Stank(smell stank.Smell) {
 	for _, interpreterExclusion := range o.InterpreterExclusions {
 		if smell.Interpreter == interpreterExclusion {
-			return nil
+			return
 		}
 	}
 
@@ -110,8 +97,6 @@ func (o Stanker) Walk(pth string, _ os.FileInfo, _ error) error {
 			o.Printer(smell.Path)
 		}
 	}
-
-	return nil
 }
 
 func main() {
@@ -133,6 +118,8 @@ func main() {
 	}
 
 	stanker.InterpreterExclusions = strings.Split(*flagExcludeInterpreters, ",")
+	stanker.WalkConfig.GitIgnore = *flagGitIgnore
+	stanker.WalkConfig.Jobs = *flagJobs
 
 	switch {
 	case *flagVersion:
@@ -145,16 +132,16 @@ func main() {
 
 	paths := flag.Args()
 
+	// Log each error as it occurs, and fail once the walk completes.
 	var observedError bool
-	var err error
-
-	for _, pth := range paths {
-		err = filepath.Walk(pth, stanker.Walk)
 
+	for smell, err := range stank.WalkSmells(stanker.WalkConfig, paths...) {
 		if err != nil {
 			log.Print(err)
 			observedError = true
 		}
+
+		stanker.Stank(smell)
 	}
 
 	if observedError {
@@ -5,10 +5,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"runtime"
 
 	"github.com/mcandre/stank"
 )
@@ -16,47 +15,34 @@ import (
 var flagPrettyPrint = flag.Bool("pp", false, "Prettyprint smell records")
 var flagEOL = flag.Bool("eol", false, "Report presence/absence of final end of line sequence")
 var flagCR = flag.Bool("cr", false, "Report presence/absence of any CR/CRLF's")
+var flagDialect = flag.Bool("dialect", false, "Report the minimal shell dialect of POSIXy scripts")
+var flagGitIgnore = flag.Bool("gitignore", true, "Skip paths excluded by .gitignore files")
+var flagJobs = flag.Int("jobs", runtime.NumCPU(), "Sniff up to this many files concurrently")
 var flagHelp = flag.Bool("help", false, "Show usage information")
 var flagVersion = flag.Bool("version", false, "Show version information")
 
 // Stinker holds configuration for a stinky walk.
 type Stinker struct {
-	// EOLCheck enables final line terminator checks.
-	EOLCheck bool
-
-	// CRCheck enables carriage return checks.
-	CRCheck bool
-
 	// PrettyPrint expands formatting.
 	PrettyPrint bool
 
-	// sniffer analyzes files.
-	sniffer stank.Sniffer
+	// WalkConfig controls file tree traversal and sniffing.
+	WalkConfig stank.WalkConfig
 }
 
 // NewStinker returns a Stinker.
 func NewStinker() Stinker {
 	var stinker Stinker
-	stinker.sniffer = stank.NewSniffer()
+	stinker.WalkConfig = stank.NewWalkConfig()
+	stinker.WalkConfig.Ignores = nil
+	stinker.WalkConfig.MachineGenerated = true
 	return stinker
 }
 
-// Walk sniffs a path,
-// printing the smell of the script.
+// Stink prints the smell of a script.
 //
 // If PrettyPrint is false, then the smell is minified.
-func (o Stinker) Walk(pth string, _ os.FileInfo, _ error) error {
-	smell, err2 := o.sniffer.Sniff(pth, stank.SniffConfig{EOLCheck: o.EOLCheck, CRCheck: o.CRCheck})
-
-	if err2 != nil && err2 != io.EOF {
-		log.Print(err2)
-		return err2
-	}
-
-	if smell.Directory {
-		return nil
-	}
-
+func (o Stinker) Stink(smell stank.Smell) {
 	var smellBytes []byte
 
 	if o.PrettyPrint {
@@ -67,7 +53,6 @@ func (o Stinker) Walk(pth string, _ os.FileInfo, _ error) error {
 
 	smellJSON := string(smellBytes)
 	fmt.Println(smellJSON)
-	return nil
 }
 
 func main() {
@@ -79,13 +64,17 @@ func main() {
 	}
 
 	if *flagEOL {
-		stinker.EOLCheck = true
+		stinker.WalkConfig.SniffConfig.EOLCheck = true
 	}
 
 	if *flagCR {
-		stinker.CRCheck = true
+		stinker.WalkConfig.SniffConfig.CRCheck = true
 	}
 
+	stinker.WalkConfig.SniffConfig.DialectCheck = *flagDialect
+	stinker.WalkConfig.GitIgnore = *flagGitIgnore
+	stinker.WalkConfig.Jobs = *flagJobs
+
 	switch {
 	case *flagVersion:
 		fmt.Println(stank.Version)
@@ -97,13 +86,12 @@ func main() {
 
 	paths := flag.Args()
 
-	var err error
-
-	for _, pth := range paths {
-		err = filepath.Walk(pth, stinker.Walk)
-
-		if err != nil && err != io.EOF {
+	for smell, err := range stank.WalkSmells(stinker.WalkConfig, paths...) {
+		if err != nil {
 			log.Print(err)
+			continue
 		}
+
+		stinker.Stink(smell)
 	}
 }
@@ -0,0 +1,285 @@
+package stank
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFilename denotes stank project configuration files.
+const ConfigFilename = ".stank.toml"
+
+// Config models project level customizations of the Sniffer tables and ignore lists.
+//
+// Table entries overlay the builtin tables: new keys extend a table,
+// while existing keys override the builtin value.
+// An empty string value removes an entry from an interpreter table.
+// Keys of lowercase tables are lowercased.
+type Config struct {
+	// Path denotes the configuration file location, if any.
+	Path string `toml:"-"`
+
+	// Ignores extends the ignore list.
+	Ignores []string `toml:"ignores"`
+
+	// ReplaceIgnores discards the default ignore list, in favor of Ignores.
+	ReplaceIgnores bool `toml:"replace_ignores"`
+
+	// AltExtensions overlays Sniffer.AltExtensions.
+	AltExtensions map[string]bool `toml:"alt_extensions"`
+
+	// AltFilenames overlays Sniffer.AltFilenames.
+	AltFilenames map[string]bool `toml:"alt_filenames"`
+
+	// AltInterpreters overlays Sniffer.AltInterpreters.
+	AltInterpreters map[string]bool `toml:"alt_interpreters"`
+
+	// FullBashInterpreters overlays Sniffer.FullBashInterpreters.
+	FullBashInterpreters map[string]bool `toml:"full_bash_interpreters"`
+
+	// InterpretersToPosixyness overlays Sniffer.InterpretersToPosixyness.
+	InterpretersToPosixyness map[string]bool `toml:"interpreters_to_posixyness"`
+
+	// KshInterpreters overlays Sniffer.KshInterpreters.
+	KshInterpreters map[string]bool `toml:"ksh_interpreters"`
+
+	// LowerExtensionsToConfig overlays Sniffer.LowerExtensionsToConfig.
+	LowerExtensionsToConfig map[string]bool `toml:"lower_extensions_to_config"`
+
+	// LowerExtensionsToInterpreter overlays Sniffer.LowerExtensionsToInterpreter.
+	LowerExtensionsToInterpreter map[string]string `toml:"lower_extensions_to_interpreter"`
+
+	// LowerExtensionsToPosixyness overlays Sniffer.LowerExtensionsToPosixyness.
+	LowerExtensionsToPosixyness map[string]bool `toml:"lower_extensions_to_posixyness"`
+
+	// LowerFilenamesToConfig overlays Sniffer.LowerFilenamesToConfig.
+	LowerFilenamesToConfig map[string]bool `toml:"lower_filenames_to_config"`
+
+	// LowerFilenamesToInterpreter overlays Sniffer.LowerFilenamesToInterpreter.
+	LowerFilenamesToInterpreter map[string]string `toml:"lower_filenames_to_interpreter"`
+
+	// LowerFilenamesToPosixyness overlays Sniffer.LowerFilenamesToPosixyness.
+	LowerFilenamesToPosixyness map[string]bool `toml:"lower_filenames_to_posixyness"`
+
+	// LowerMachineExtensions overlays Sniffer.LowerMachineExtensions.
+	LowerMachineExtensions map[string]bool `toml:"lower_machine_extensions"`
+
+	// Funk customizes funk rule selection.
+	Funk FunkConfig `toml:"funk"`
+}
+
+// FunkConfig models funk rule selection.
+type FunkConfig struct {
+	// Profile names a base rule selection from Profiles.
+	Profile string `toml:"profile"`
+
+	// Enable activates rules.
+	Enable []string `toml:"enable"`
+
+	// Disable deactivates rules.
+	Disable []string `toml:"disable"`
+
+	// Severity overrides rule severities.
+	Severity map[string]Severity `toml:"severity"`
+
+	// InterpreterPairings lists additional shebang interpreters acceptable for each lowercase extension,
+	// like ".sh" = ["bash"].
+	InterpreterPairings map[string][]string `toml:"interpreter_pairings"`
+
+	// ShebangPrefix precedes the interpreter name in shebangs repaired by funk -fix,
+	// like DefaultShebangPrefix.
+	ShebangPrefix string `toml:"shebang_prefix"`
+
+	// Format configures the canonical printing for the format rule.
+	Format FormatOptions `toml:"format"`
+}
+
+// FindConfigs searches for configuration files,
+// beginning with the directory of pth and proceeding upward to the file system root.
+//
+// Paths are ordered outermost first, so that nearer configuration takes precedence.
+func FindConfigs(pth string) ([]string, error) {
+	abs, err := filepath.Abs(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dir := abs
+
+	if fi, err2 := os.Stat(abs); err2 == nil && !fi.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+
+	var paths []string
+
+	for {
+		candidate := filepath.Join(dir, ConfigFilename)
+
+		if _, err2 := os.Stat(candidate); err2 == nil {
+			paths = append(paths, candidate)
+		} else if !errors.Is(err2, os.ErrNotExist) {
+			return nil, err2
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			slices.Reverse(paths)
+			return paths, nil
+		}
+
+		dir = parent
+	}
+}
+
+// LoadConfig parses a configuration file.
+//
+// Unrecognized keys are reported as errors, in order to catch typos.
+func LoadConfig(pth string) (Config, error) {
+	contents, err := os.ReadFile(pth)
+
+	if err != nil {
+		return Config{}, err
+	}
+
+	return ParseConfig(pth, contents)
+}
+
+// ParseConfig parses configuration file contents, located at pth.
+//
+// Unrecognized keys are reported as errors, in order to catch typos.
+func ParseConfig(pth string, contents []byte) (Config, error) {
+	var config Config
+
+	md, err := toml.Decode(string(contents), &config)
+
+	if err != nil {
+		return config, fmt.Errorf("%s: %v", pth, err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) != 0 {
+		keys := make([]string, len(undecoded))
+
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+
+		return config, fmt.Errorf("%s: unknown keys: %s", pth, strings.Join(keys, ", "))
+	}
+
+	config.Path = pth
+	return config, nil
+}
+
+// DiscoverConfigs locates and loads the configuration files governing pth, outermost first.
+//
+// Each configuration overlays the ones above it.
+func DiscoverConfigs(pth string) ([]Config, error) {
+	configPaths, err := FindConfigs(pth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Config
+
+	for _, configPath := range configPaths {
+		config, err2 := LoadConfig(configPath)
+
+		if err2 != nil {
+			return nil, err2
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// OverlayIgnores applies the configured ignore list to a base ignore list.
+func (o Config) OverlayIgnores(ignores []string) []string {
+	if o.ReplaceIgnores {
+		return append([]string{}, o.Ignores...)
+	}
+
+	return append(append([]string{}, ignores...), o.Ignores...)
+}
+
+// overlayBools merges a configuration table onto a copy of a base table.
+func overlayBools(base map[string]bool, overlay map[string]bool, lower bool) map[string]bool {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	result := maps.Clone(base)
+
+	if result == nil {
+		result = map[string]bool{}
+	}
+
+	for k, v := range overlay {
+		if lower {
+			k = strings.ToLower(k)
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// overlayStrings merges a configuration table onto a copy of a base table.
+// Empty values remove entries.
+func overlayStrings(base map[string]string, overlay map[string]string, lower bool) map[string]string {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	result := maps.Clone(base)
+
+	if result == nil {
+		result = map[string]string{}
+	}
+
+	for k, v := range overlay {
+		if lower {
+			k = strings.ToLower(k)
+		}
+
+		if v == "" {
+			delete(result, k)
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// Overlay applies the configured tables to a Sniffer.
+//
+// The base Sniffer tables are copied rather than modified.
+func (o Config) Overlay(sniffer Sniffer) Sniffer {
+	sniffer.AltExtensions = overlayBools(sniffer.AltExtensions, o.AltExtensions, true)
+	sniffer.AltFilenames = overlayBools(sniffer.AltFilenames, o.AltFilenames, true)
+	sniffer.AltInterpreters = overlayBools(sniffer.AltInterpreters, o.AltInterpreters, false)
+	sniffer.FullBashInterpreters = overlayBools(sniffer.FullBashInterpreters, o.FullBashInterpreters, false)
+	sniffer.InterpretersToPosixyness = overlayBools(sniffer.InterpretersToPosixyness, o.InterpretersToPosixyness, false)
+	sniffer.KshInterpreters = overlayBools(sniffer.KshInterpreters, o.KshInterpreters, false)
+	sniffer.LowerExtensionsToConfig = overlayBools(sniffer.LowerExtensionsToConfig, o.LowerExtensionsToConfig, true)
+	sniffer.LowerExtensionsToInterpreter = overlayStrings(sniffer.LowerExtensionsToInterpreter, o.LowerExtensionsToInterpreter, true)
+	sniffer.LowerExtensionsToPosixyness = overlayBools(sniffer.LowerExtensionsToPosixyness, o.LowerExtensionsToPosixyness, true)
+	sniffer.LowerFilenamesToConfig = overlayBools(sniffer.LowerFilenamesToConfig, o.LowerFilenamesToConfig, true)
+	sniffer.LowerFilenamesToInterpreter = overlayStrings(sniffer.LowerFilenamesToInterpreter, o.LowerFilenamesToInterpreter, true)
+	sniffer.LowerFilenamesToPosixyness = overlayBools(sniffer.LowerFilenamesToPosixyness, o.LowerFilenamesToPosixyness, true)
+	sniffer.LowerMachineExtensions = overlayBools(sniffer.LowerMachineExtensions, o.LowerMachineExtensions, true)
+	return sniffer
+}
@@ -0,0 +1,180 @@
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestDiscoverConfigOverlaysSniffer(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, stank.ConfigFilename)
+
+	configTOML := `ignores = ["dist"]
+
+[lower_filenames_to_posixyness]
+".envrc.local" = true
+
+[lower_extensions_to_posixyness]
+".SHLIB" = true
+
+[interpreters_to_posixyness]
+corpsh = true
+`
+
+	if err := os.WriteFile(configPath, []byte(configTOML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	nested := filepath.Join(root, "a", "b")
+
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := stank.DiscoverConfigs(nested)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(configs) == 0 || configs[len(configs)-1].Path != configPath {
+		t.Fatalf("expected nearest config path %v, got %v", configPath, configs)
+	}
+
+	config := configs[len(configs)-1]
+
+	sniffer := config.Overlay(stank.NewSniffer())
+
+	if !sniffer.LowerExtensionsToPosixyness[".shlib"] {
+		t.Errorf("expected .shlib to be POSIXy")
+	}
+
+	if !sniffer.InterpretersToPosixyness["corpsh"] {
+		t.Errorf("expected corpsh to be POSIXy")
+	}
+
+	if stank.InterpretersToPosixyness()["corpsh"] {
+		t.Errorf("expected builtin tables to remain unmodified")
+	}
+
+	script := filepath.Join(nested, "deploy")
+
+	if err2 := os.WriteFile(script, []byte("#!/opt/corp/bin/corpsh\necho hi\n"), 0755); err2 != nil {
+		t.Fatal(err2)
+	}
+
+	smell, err := sniffer.Sniff(script, stank.SniffConfig{})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !smell.POSIXy || smell.Interpreter != "corpsh" {
+		t.Errorf("expected POSIXy corpsh smell, got %v", smell)
+	}
+
+	ignores := config.OverlayIgnores(stank.Ignores())
+
+	if !stank.IgnoreAny(filepath.Join("x", "dist", "y"), ignores) || !stank.IgnoreAny(filepath.Join("x", "vendor"), ignores) {
+		t.Errorf("expected extended ignores, got %v", ignores)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), stank.ConfigFilename)
+
+	if err := os.WriteFile(configPath, []byte("ignroes = [\"dist\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stank.LoadConfig(configPath); err == nil {
+		t.Errorf("expected unknown key error")
+	}
+}
+
+func TestWalkOverlaysNestedConfig(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		stank.ConfigFilename:               "ignores = [\"dist\"]\n",
+		"util.shlib":                       "greet() {\n\techo hi\n}\n",
+		"sub/" + stank.ConfigFilename:      "[lower_extensions_to_posixyness]\n\".shlib\" = true\n",
+		"sub/util.shlib":                   "greet() {\n\techo hi\n}\n",
+		"sub/dist/build.sh":                "#!/bin/sh\n",
+		"sub/deep/" + stank.ConfigFilename: "ignores = [\"tmp\"]\n",
+		"sub/deep/util.shlib":              "greet() {\n\techo hi\n}\n",
+		"sub/deep/tmp/scratch.sh":          "#!/bin/sh\n",
+	}
+
+	for name, contents := range files {
+		pth := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(pth, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := stank.NewWalkConfig()
+	config.GitIgnore = false
+
+	expected := map[string]struct {
+		posixy  bool
+		configs int
+	}{
+		"util.shlib":          {false, 1},
+		"sub/util.shlib":      {true, 2},
+		"sub/deep/util.shlib": {true, 3},
+	}
+
+	// Results agree regardless of the directory named as the walk root.
+	for _, walkRoot := range []string{root, filepath.Join(root, "sub")} {
+		for analysis, err := range stank.WalkAnalyses(config, walkRoot) {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel, err := filepath.Rel(root, analysis.Smell.Path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rel = filepath.ToSlash(rel)
+
+			if filepath.Ext(rel) == ".sh" {
+				t.Errorf("walking %v, expected nested ignores to skip %v", walkRoot, rel)
+			}
+
+			if e, ok := expected[rel]; ok && (analysis.Smell.POSIXy != e.posixy || len(analysis.Configs) != e.configs) {
+				t.Errorf("walking %v, expected %v POSIXy %v under %d configs, got %v under %d", walkRoot, rel, e.posixy, e.configs, analysis.Smell.POSIXy, len(analysis.Configs))
+			}
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "sub", "deep", stank.ConfigFilename), []byte("ignroes = [\"tmp\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs int
+
+	for smell, err := range stank.WalkSmells(config, root) {
+		if err != nil {
+			errs++
+		}
+
+		if smell.Path == filepath.Join(root, "sub", "deep", "util.shlib") {
+			t.Errorf("expected misconfigured directory to be skipped")
+		}
+	}
+
+	if errs != 1 {
+		t.Errorf("expected one nested config error, got %d", errs)
+	}
+}
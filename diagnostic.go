@@ -0,0 +1,216 @@
+package stank
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Severity ranks the importance of a diagnostic.
+type Severity int
+
+const (
+	// SeverityInfo denotes stylistic advice.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning denotes likely portability, safety, or security hazards.
+	SeverityWarning
+
+	// SeverityError denotes scripts which are likely broken.
+	SeverityError
+)
+
+// String renders a severity as a lowercase name.
+func (o Severity) String() string {
+	switch o {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(o))
+	}
+}
+
+// ParseSeverity reads a lowercase severity name.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return SeverityInfo, fmt.Errorf("unknown severity: %v", s)
+	}
+}
+
+// MarshalText renders a severity name.
+func (o Severity) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText reads a severity name.
+func (o *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*o = severity
+	return nil
+}
+
+const (
+	// RuleBOM flags leading byte order markers.
+	RuleBOM = "bom"
+
+	// RuleCR flags CR/CRLF line endings.
+	RuleCR = "cr"
+
+	// RuleEOL flags a missing final end of line sequence.
+	RuleEOL = "eol"
+
+	// RuleShebang flags missing or malformed shebangs.
+	RuleShebang = "shebang"
+
+	// RulePermissions flags ambiguous file mode bits.
+	RulePermissions = "permissions"
+
+	// RuleModulino flags scripts mixing application and library traits.
+	RuleModulino = "modulino"
+
+	// RuleInterpreter flags interpreters unavailable for syntax validation.
+	RuleInterpreter = "interpreter"
+
+	// RuleSyntax flags syntax errors.
+	RuleSyntax = "syntax"
+
+	// RuleAltSyntax flags syntax errors in alternative shell scripts, such as fish and csh scripts.
+	RuleAltSyntax = "alt-syntax"
+
+	// RuleIFSReset flags executable scripts lacking an early IFS reset.
+	RuleIFSReset = "ifs-reset"
+
+	// RuleSafetyFlags flags executable scripts lacking early set safety flags.
+	RuleSafetyFlags = "safety-flags"
+
+	// RuleTrapHazards flags traps at risk of colliding with other control flow.
+	RuleTrapHazards = "trap-hazards"
+
+	// RuleInterpreterMismatch flags shebangs conflicting with file extensions.
+	RuleInterpreterMismatch = "interpreter-mismatch"
+
+	// RuleBashisms flags non-POSIX constructs in POSIX sh scripts.
+	RuleBashisms = "bashisms"
+
+	// RuleDialect flags interpreters requesting more or less than the script needs.
+	RuleDialect = "dialect"
+
+	// RuleFormat flags scripts whose formatting differs from the canonical print.
+	RuleFormat = "format"
+
+	// RuleUnusedSuppression flags funk:disable directives which suppress nothing.
+	RuleUnusedSuppression = "unused-suppression"
+)
+
+// Rule describes a lint check.
+type Rule struct {
+	// ID uniquely identifies the rule.
+	ID string
+
+	// Severity is the default severity of the rule's diagnostics.
+	Severity Severity
+
+	// Description summarizes the rule.
+	Description string
+}
+
+// Rules catalogs the lint checks, by ID.
+var Rules = sync.OnceValue(func() map[string]Rule {
+	rules := []Rule{
+		{RuleBOM, SeverityWarning, "Leading byte order markers reduce portability"},
+		{RuleCR, SeverityWarning, "CR/CRLF line endings confuse POSIX interpreters"},
+		{RuleEOL, SeverityWarning, "Text files should end with a final end of line sequence"},
+		{RuleShebang, SeverityWarning, "Shebangs should be present, absolute, and free of flags"},
+		{RulePermissions, SeverityWarning, "Launch style should be signaled by either an extension or executable bits"},
+		{RuleModulino, SeverityInfo, "Scripts should be either pure applications or pure libraries"},
+		{RuleInterpreter, SeverityWarning, "Interpreters should be available for syntax validation"},
+		{RuleSyntax, SeverityError, "Scripts should parse"},
+		{RuleAltSyntax, SeverityError, "Alternative shell scripts should parse"},
+		{RuleIFSReset, SeverityWarning, "Executable scripts should reset IFS near the top"},
+		{RuleSafetyFlags, SeverityWarning, "Executable scripts should set safety flags near the top"},
+		{RuleTrapHazards, SeverityWarning, "Traps should not collide with other control flow"},
+		{RuleInterpreterMismatch, SeverityWarning, "Shebangs should agree with file extensions"},
+		{RuleBashisms, SeverityWarning, "POSIX sh scripts should avoid bash extensions"},
+		{RuleDialect, SeverityWarning, "Interpreters should match the minimal dialect which parses the script"},
+		{RuleFormat, SeverityInfo, "Scripts should match the canonical formatting"},
+		{RuleUnusedSuppression, SeverityInfo, "Suppression directives should name known rules with findings to suppress"},
+	}
+
+	m := make(map[string]Rule, len(rules))
+
+	for _, rule := range rules {
+		m[rule.ID] = rule
+	}
+
+	return m
+})
+
+// Diagnostic describes a lint finding.
+type Diagnostic struct {
+	// Rule identifies the check.
+	Rule string `json:"rule"`
+
+	// Severity ranks the finding.
+	Severity Severity `json:"severity"`
+
+	// Path locates the file.
+	Path string `json:"path"`
+
+	// Line is the 1-based line number, or zero for findings about the file as a whole.
+	Line int `json:"line,omitempty"`
+
+	// Column is the 1-based column number, or zero when unknown.
+	Column int `json:"column,omitempty"`
+
+	// Message describes the finding.
+	Message string `json:"message"`
+
+	// Fix optionally suggests a remedy.
+	Fix string `json:"fix,omitempty"`
+
+	// Fingerprint optionally identifies the finding across edits, for baselines.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// NewDiagnostic constructs a Diagnostic with the rule's default severity.
+func NewDiagnostic(rule string, pth string, message string) Diagnostic {
+	return Diagnostic{
+		Rule:     rule,
+		Severity: Rules()[rule].Severity,
+		Path:     pth,
+		Message:  message,
+	}
+}
+
+// Location renders the path, line, and column, as available.
+func (o Diagnostic) Location() string {
+	switch {
+	case o.Line == 0:
+		return o.Path
+	case o.Column == 0:
+		return fmt.Sprintf("%v:%d", o.Path, o.Line)
+	default:
+		return fmt.Sprintf("%v:%d:%d", o.Path, o.Line, o.Column)
+	}
+}
+
+// String renders a diagnostic in the classic funk text format.
+func (o Diagnostic) String() string {
+	return fmt.Sprintf("%v: %v", o.Message, o.Path)
+}
@@ -0,0 +1,55 @@
+package stank_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestDiagnosticString(t *testing.T) {
+	diagnostic := stank.NewDiagnostic(stank.RuleSyntax, "hello.sh", "sh syntax error: reached EOF")
+
+	if diagnostic.Severity != stank.SeverityError {
+		t.Errorf("expected syntax rule severity error, got %v", diagnostic.Severity)
+	}
+
+	if observed := diagnostic.String(); observed != "sh syntax error: reached EOF: hello.sh" {
+		t.Errorf("expected location-free rendering, got %v", observed)
+	}
+
+	diagnostic.Line = 2
+	diagnostic.Column = 6
+
+	if observed := diagnostic.String(); observed != "sh syntax error: reached EOF: hello.sh" {
+		t.Errorf("expected classic rendering, got %v", observed)
+	}
+
+	if observed := diagnostic.Location(); observed != "hello.sh:2:6" {
+		t.Errorf("expected located rendering, got %v", observed)
+	}
+}
+
+func TestSeverityJSON(t *testing.T) {
+	for _, severity := range []stank.Severity{stank.SeverityInfo, stank.SeverityWarning, stank.SeverityError} {
+		bs, err := json.Marshal(severity)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var severity2 stank.Severity
+
+		if err := json.Unmarshal(bs, &severity2); err != nil {
+			t.Fatal(err)
+		}
+
+		if severity2 != severity {
+			t.Errorf("expected %v to round trip through %s, got %v", severity, bs, severity2)
+		}
+	}
+
+	if _, err := stank.ParseSeverity("catastrophic"); err == nil {
+		t.Errorf("expected unknown severity error")
+	}
+}
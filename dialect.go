@@ -0,0 +1,67 @@
+package stank
+
+import (
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Dialects orders shell parser languages from least to most powerful.
+var Dialects = []syntax.LangVariant{
+	syntax.LangPOSIX,
+	syntax.LangMirBSDKorn,
+	syntax.LangBash,
+	syntax.LangZsh,
+}
+
+// DialectRank orders dialect names by power, per Dialects.
+//
+// Unknown dialects yield -1.
+func DialectRank(dialect string) int {
+	return slices.IndexFunc(Dialects, func(variant syntax.LangVariant) bool {
+		return variant.String() == dialect
+	})
+}
+
+// DeclaredDialect names the dialect requested by a POSIXy script's interpreter, per Dialects.
+//
+// Interpreters without a corresponding dialect, such as bats or unknown shells, yield an empty string.
+func (o Sniffer) DeclaredDialect(smell Smell) string {
+	switch {
+	case POSIXShInterpreters()[smell.Interpreter]:
+		return syntax.LangPOSIX.String()
+	case o.FullBashInterpreters[smell.Interpreter], o.KshInterpreters[smell.Interpreter], smell.Interpreter == "zsh":
+		return o.Variant(smell.Interpreter).String()
+	default:
+		return ""
+	}
+}
+
+// MismatchedInterpreter reports the interpreter implied by a smell's extension,
+// when the shebang interpreter conflicts with it.
+//
+// Interpreters agree when they are identical, when they share a dialect,
+// or when the pairings list the shebang interpreter as acceptable for the lowercase extension.
+// Core configuration files, such as .profile, are exempt.
+// Otherwise, MismatchedInterpreter yields an empty string.
+func (o Sniffer) MismatchedInterpreter(smell Smell, pairings map[string][]string) string {
+	if smell.Shebang == "" || smell.Interpreter == "" || smell.CoreConfiguration {
+		return ""
+	}
+
+	extension := strings.ToLower(smell.Extension)
+	extensionInterpreter, ok := o.LowerExtensionsToInterpreter[extension]
+
+	if !ok || extensionInterpreter == smell.Interpreter || slices.Contains(pairings[extension], smell.Interpreter) {
+		return ""
+	}
+
+	dialect := o.DeclaredDialect(Smell{Interpreter: smell.Interpreter})
+
+	if dialect != "" && dialect == o.DeclaredDialect(Smell{Interpreter: extensionInterpreter}) {
+		return ""
+	}
+
+	return extensionInterpreter
+}
@@ -0,0 +1,181 @@
+package stank_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestAnalysisDialect(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		interpreter string
+		contents    string
+		expected    string
+	}{
+		{"sh", "echo hi\n", "posix"},
+		{"bash", "echo hi\n", "posix"},
+		{"bash", "a=(1 2)\n", "mksh"},
+		{"bash", "echo \"${A^^}\"\n", "bash"},
+		{"zsh", "echo ${(U)A}\n", "zsh"},
+		{"bash", "if then\n", ""},
+	} {
+		analysis := sniffer.NewAnalysis(stank.Smell{Path: "hello", POSIXy: true, Interpreter: tc.interpreter}, []byte(tc.contents))
+
+		if dialect := analysis.Dialect(); dialect != tc.expected {
+			t.Errorf("expected %q for %v %q, got %q", tc.expected, tc.interpreter, tc.contents, dialect)
+		}
+	}
+}
+
+func TestDeclaredDialect(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		interpreter string
+		expected    string
+	}{
+		{"sh", "posix"},
+		{"generic-sh", "posix"},
+		{"ksh93", "mksh"},
+		{"bash", "bash"},
+		{"zsh", "zsh"},
+		{"bats", ""},
+	} {
+		if dialect := sniffer.DeclaredDialect(stank.Smell{Interpreter: tc.interpreter}); dialect != tc.expected {
+			t.Errorf("expected %q for %v, got %q", tc.expected, tc.interpreter, dialect)
+		}
+	}
+
+	if stank.DialectRank("posix") >= stank.DialectRank("bash") || stank.DialectRank("csh") != -1 {
+		t.Errorf("expected dialects ranked by power")
+	}
+}
+
+func TestSniffDialect(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	smell, err := sniffer.SniffBytes("hello", 0755, []byte("#!/bin/bash\na=(1 2)\n"), stank.SniffConfig{DialectCheck: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if smell.Dialect != "mksh" {
+		t.Errorf("expected dialect mksh, got %q", smell.Dialect)
+	}
+
+	contents := []byte("#!/bin/bash\na=(1 2)\n")
+	smell2, err := sniffer.SniffReader("hello", 0755, bytes.NewReader(contents), int64(len(contents)), stank.SniffConfig{DialectCheck: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(smell2, smell) {
+		t.Errorf("expected SniffReader to agree with SniffBytes, got %v and %v", smell2, smell)
+	}
+
+	smell, err = sniffer.SniffBytes("hello", 0755, []byte("#!/bin/bash\na=(1 2)\n"), stank.SniffConfig{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if smell.Dialect != "" {
+		t.Errorf("expected no dialect without DialectCheck, got %q", smell.Dialect)
+	}
+
+	pth := filepath.Join(t.TempDir(), "hello")
+
+	if err := os.WriteFile(pth, []byte("#!/bin/bash\necho ${A^^}\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := sniffer.Analyze(pth, stank.SniffConfig{DialectCheck: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if analysis.Smell.Dialect != "bash" || analysis.Dialect() != "bash" {
+		t.Errorf("expected dialect bash, got %q", analysis.Smell.Dialect)
+	}
+
+	if smell, err := sniffer.Sniff(pth, stank.SniffConfig{DialectCheck: true}); err != nil || smell.Dialect != "bash" {
+		t.Errorf("expected sniffed dialect bash, got %q (%v)", smell.Dialect, err)
+	}
+}
+
+func TestMismatchedInterpreter(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		extension   string
+		interpreter string
+		pairings    map[string][]string
+		expected    string
+	}{
+		{".sh", "bash", nil, "sh"},
+		{".bash", "sh", nil, "bash"},
+		{".zsh", "bash", nil, "zsh"},
+		{".ksh", "bash", nil, "ksh"},
+		{".ksh", "mksh", nil, ""},
+		{".sh", "dash", nil, ""},
+		{".sh", "bash", map[string][]string{".sh": {"bash"}}, ""},
+		{".SH", "bash", map[string][]string{".sh": {"bash"}}, ""},
+		{".cluster", "bash", nil, ""},
+	} {
+		smell := stank.Smell{Extension: tc.extension, Shebang: "#!/bin/" + tc.interpreter, Interpreter: tc.interpreter}
+
+		if extensionInterpreter := sniffer.MismatchedInterpreter(smell, tc.pairings); extensionInterpreter != tc.expected {
+			t.Errorf("expected %q for %v with %v, got %q", tc.expected, tc.extension, tc.interpreter, extensionInterpreter)
+		}
+	}
+
+	profile := stank.Smell{Filename: ".profile", Extension: ".profile", Shebang: "#!/bin/bash", Interpreter: "bash", CoreConfiguration: true}
+
+	if extensionInterpreter := sniffer.MismatchedInterpreter(profile, nil); extensionInterpreter != "" {
+		t.Errorf("expected core configuration files exempt, got %q", extensionInterpreter)
+	}
+}
+
+func TestConfiguredInterpreterPairings(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, stank.ConfigFilename)
+
+	if err := os.WriteFile(configPath, []byte("[funk.interpreter_pairings]\n\".sh\" = [\"bash\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "build.sh")
+
+	if err := os.WriteFile(script, []byte("#!/bin/bash\necho hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := stank.LoadConfig(configPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sniffer := config.Overlay(stank.NewSniffer())
+	smell, err := sniffer.Sniff(script, stank.SniffConfig{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extensionInterpreter := sniffer.MismatchedInterpreter(smell, nil); extensionInterpreter != "sh" {
+		t.Errorf("expected a mismatch without pairings, got %q", extensionInterpreter)
+	}
+
+	if extensionInterpreter := sniffer.MismatchedInterpreter(smell, config.Funk.InterpreterPairings); extensionInterpreter != "" {
+		t.Errorf("expected configured pairings to suppress the mismatch, got %q", extensionInterpreter)
+	}
+}
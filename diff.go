@@ -0,0 +1,283 @@
+package stank
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DiffContext counts the unchanged lines surrounding each change in a unified diff.
+const DiffContext = 3
+
+// diffOp models a line level edit.
+type diffOp struct {
+	// kind is ' ' for equal lines, '-' for deletions, and '+' for insertions.
+	kind byte
+
+	// line holds the text, including any line ending.
+	line string
+}
+
+// splitDiffLines divides text into lines, retaining line endings.
+func splitDiffLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// differ accumulates a shortest edit script, reusing the diagonal vectors across subproblems.
+type differ struct {
+	// ops collects the edits.
+	ops []diffOp
+
+	// vf and vb hold the furthest reaching x per diagonal, for the forward and backward searches.
+	vf, vb []int
+}
+
+// diffLines computes a shortest edit script, per the linear space refinement of Myers' O(ND) algorithm.
+//
+// Within each run of changes, deletions precede insertions.
+func diffLines(a []string, b []string) []diffOp {
+	size := 2*((len(a)+len(b)+1)/2) + 3
+	o := &differ{vf: make([]int, size), vb: make([]int, size)}
+	o.diff(a, b)
+	return groupChanges(o.ops)
+}
+
+// emit appends an edit per line.
+func (o *differ) emit(kind byte, lines []string) {
+	for _, line := range lines {
+		o.ops = append(o.ops, diffOp{kind, line})
+	}
+}
+
+// diff appends the edits transforming a into b, dividing the problem at a middle snake.
+func (o *differ) diff(a []string, b []string) {
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	o.emit(' ', a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		o.emit('+', b)
+	case len(b) == 0:
+		o.emit('-', a)
+	default:
+		x, y, u, v := o.middleSnake(a, b)
+		o.diff(a[:x], b[:y])
+		o.emit(' ', a[x:u])
+		o.diff(a[u:], b[v:])
+	}
+
+	o.emit(' ', common)
+}
+
+// middleSnake locates the start (x, y) and end (u, v) of the middle snake of a shortest edit path,
+// searching forward from the start and backward from the end until the paths overlap.
+func (o *differ) middleSnake(a []string, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	vf, vb := o.vf, o.vb
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+
+			y = x - k
+			u, v = x, y
+
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+
+			vf[offset+k] = u
+
+			if odd && delta-k >= -(d-1) && delta-k <= d-1 && u+vb[offset+delta-k] >= n {
+				return x, y, u, v
+			}
+		}
+
+		// Backward coordinates count from the end of each sequence.
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+
+			y = x - k
+			u, v = x, y
+
+			for u < n && v < m && a[n-1-u] == b[m-1-v] {
+				u++
+				v++
+			}
+
+			vb[offset+k] = u
+
+			if !odd && delta-k >= -d && delta-k <= d && u+vf[offset+delta-k] >= n {
+				return n - u, m - v, n - x, m - y
+			}
+		}
+	}
+
+	panic("no middle snake")
+}
+
+// groupChanges reorders each run of changes so that deletions precede insertions.
+func groupChanges(ops []diffOp) []diffOp {
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		j := i
+
+		for j < len(ops) && ops[j].kind != ' ' {
+			j++
+		}
+
+		slices.SortStableFunc(ops[i:j], func(p diffOp, q diffOp) int {
+			return int(q.kind) - int(p.kind)
+		})
+
+		i = j
+	}
+
+	return ops
+}
+
+// hunkRange renders a unified diff line range.
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// UnifiedDiff renders the line differences between two texts, or an empty string when they are identical.
+func UnifiedDiff(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+
+	ops := diffLines(splitDiffLines(from), splitDiffLines(to))
+
+	var changes []int
+
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %v\n+++ %v\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		// Merge changes separated by overlapping context.
+		j := i
+
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*DiffContext+1 {
+			j++
+		}
+
+		start := max(changes[i]-DiffContext, 0)
+		end := min(changes[j]+DiffContext+1, len(ops))
+
+		var fromStart, toStart, fromLength, toLength int
+
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				fromStart++
+			}
+
+			if op.kind != '-' {
+				toStart++
+			}
+		}
+
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				fromLength++
+			}
+
+			if op.kind != '-' {
+				toLength++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%v +%v @@\n", hunkRange(fromStart, fromLength), hunkRange(toStart, toLength))
+
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = j + 1
+	}
+
+	return sb.String()
+}
+
+// gitMode renders file permissions in git's octal notation.
+func gitMode(permissions fs.FileMode) string {
+	return fmt.Sprintf("100%03o", permissions.Perm())
+}
+
+// Diff renders the pending rewrites as a git style unified diff, or an empty string when nothing changes.
+func (o Fix) Diff() string {
+	if !o.ContentsChanged() && !o.PermissionsChanged() {
+		return ""
+	}
+
+	pth := strings.TrimPrefix(filepath.ToSlash(o.Analysis.Smell.Path), "/")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%v b/%v\n", pth, pth)
+
+	if o.PermissionsChanged() {
+		fmt.Fprintf(&sb, "old mode %v\nnew mode %v\n", gitMode(o.Analysis.Smell.Permissions), gitMode(o.Permissions))
+	}
+
+	sb.WriteString(UnifiedDiff("a/"+pth, "b/"+pth, string(o.Analysis.Bytes), string(o.Contents)))
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package stank_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tc := range []struct {
+		from     string
+		to       string
+		expected string
+	}{
+		{"a\n", "a\n", ""},
+		{
+			"echo hi",
+			"echo hi\n",
+			"--- a\n+++ b\n@@ -1 +1 @@\n-echo hi\n\\ No newline at end of file\n+echo hi\n",
+		},
+		{
+			"",
+			"#!/bin/sh\n",
+			"--- a\n+++ b\n@@ -0,0 +1 @@\n+#!/bin/sh\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"--- a\n+++ b\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -10,3 +11,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n",
+			"1\nx\n3\n4\ny\n",
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n 4\n-5\n+y\n",
+		},
+	} {
+		if diff := stank.UnifiedDiff("a", "b", tc.from, tc.to); diff != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, diff)
+		}
+	}
+}
+
+func TestFixDiff(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	analysis := sniffer.NewAnalysis(stank.Smell{Path: "bin/hello", POSIXy: true, Permissions: 0644}, []byte("#!/bin/sh\r\necho hi\r\n"))
+	fix := sniffer.NewFix(analysis)
+
+	if fix.Diff() != "" {
+		t.Errorf("expected empty diff before fixes")
+	}
+
+	fix.Apply([]stank.Diagnostic{
+		stank.NewDiagnostic(stank.RuleCR, "bin/hello", "CR/CRLF line ending detected"),
+		stank.NewDiagnostic(stank.RulePermissions, "bin/hello", "Ambiguous launch style. Either feature a file extensions, or else feature executable bits"),
+	})
+
+	expected := "diff --git a/bin/hello b/bin/hello\n" +
+		"old mode 100644\n" +
+		"new mode 100755\n" +
+		"--- a/bin/hello\n" +
+		"+++ b/bin/hello\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"-#!/bin/sh\r\n" +
+		"-echo hi\r\n" +
+		"+#!/bin/sh\n" +
+		"+echo hi\n"
+
+	if diff := fix.Diff(); diff != expected {
+		t.Errorf("expected %q, got %q", expected, diff)
+	}
+}
+
+func TestUnifiedDiffRewritesEveryLine(t *testing.T) {
+	var from, to strings.Builder
+
+	for i := 0; i < 6000; i++ {
+		fmt.Fprintf(&from, "echo line%v\r\n", i)
+		fmt.Fprintf(&to, "echo line%v\n", i)
+	}
+
+	diff := stank.UnifiedDiff("a", "b", from.String(), to.String())
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+
+	if len(lines) != 3+2*6000 || lines[2] != "@@ -1,6000 +1,6000 @@" {
+		t.Fatalf("expected a single hunk replacing 6000 lines, got %v lines", len(lines))
+	}
+
+	if lines[3] != "-echo line0\r" || lines[6002] != "-echo line5999\r" || lines[6003] != "+echo line0" {
+		t.Errorf("expected deletions ahead of insertions, got %q, %q, %q", lines[3], lines[6002], lines[6003])
+	}
+}
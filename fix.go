@@ -0,0 +1,225 @@
+package stank
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Fix accumulates automated rewrites of a script's contents and permissions.
+type Fix struct {
+	// Analysis describes the original file.
+	Analysis Analysis
+
+	// Sniffer supplies classification tables to fixers.
+	Sniffer Sniffer
+
+	// ShebangPrefix precedes the interpreter name in repaired shebangs, like DefaultShebangPrefix.
+	ShebangPrefix string
+
+	// FormatOptions configures canonical printing for format fixes.
+	FormatOptions FormatOptions
+
+	// Contents holds the rewritten file contents.
+	Contents []byte
+
+	// Permissions holds the rewritten file mode permission bits.
+	Permissions fs.FileMode
+
+	// Fixed collects the addressed diagnostics.
+	Fixed []Diagnostic
+}
+
+// NewFix constructs a Fix, initially identical to the original file.
+func (o Sniffer) NewFix(analysis Analysis) *Fix {
+	return &Fix{
+		Analysis:      analysis,
+		Sniffer:       o,
+		ShebangPrefix: DefaultShebangPrefix,
+		Contents:      slices.Clone(analysis.Bytes),
+		Permissions:   analysis.Smell.Permissions,
+	}
+}
+
+// Fixer attempts to address a diagnostic, reporting whether the diagnostic is resolved.
+type Fixer func(fix *Fix, diagnostic Diagnostic) bool
+
+// Fixers catalogs the automated remedies, by rule ID.
+var Fixers = sync.OnceValue(func() map[string]Fixer {
+	return map[string]Fixer{
+		RuleBOM:         FixBOM,
+		RuleCR:          FixCR,
+		RuleEOL:         FixEOL,
+		RuleFormat:      FixFormat,
+		RuleIFSReset:    FixIFSReset,
+		RuleModulino:    FixPermissions,
+		RulePermissions: FixPermissions,
+		RuleSafetyFlags: FixSafetyFlags,
+		RuleShebang:     FixShebang,
+	}
+})
+
+// FixBOM strips a leading byte order marker.
+//
+// Longer markers are tried first, as some markers prefix others, such as UTF-16LE and UTF-32LE.
+func FixBOM(fix *Fix, diagnostic Diagnostic) bool {
+	for i := 5; i > 1; i-- {
+		if len(fix.Contents) >= i && fix.Sniffer.IsBOM(fix.Contents[:i]) {
+			fix.Contents = fix.Contents[i:]
+			return true
+		}
+	}
+
+	return false
+}
+
+// FixCR converts CRLF and CR line endings to LF.
+func FixCR(fix *Fix, diagnostic Diagnostic) bool {
+	fix.Contents = bytes.ReplaceAll(fix.Contents, []byte("\r\n"), []byte("\n"))
+	fix.Contents = bytes.ReplaceAll(fix.Contents, []byte("\r"), []byte("\n"))
+	return true
+}
+
+// FixEOL appends a missing final LF.
+func FixEOL(fix *Fix, diagnostic Diagnostic) bool {
+	if !bytes.HasSuffix(fix.Contents, []byte("\n")) {
+		fix.Contents = append(fix.Contents, '\n')
+	}
+
+	return true
+}
+
+// FixFormat reprints the contents canonically, per FormatOptions.
+//
+// Contents which fail to parse are left untouched.
+func FixFormat(fix *Fix, diagnostic Diagnostic) bool {
+	smell := fix.Analysis.Smell
+	contents, err := FormatShell(smell.Path, fix.Contents, fix.Sniffer.Variant(smell.Interpreter), fix.FormatOptions)
+
+	if err != nil {
+		return false
+	}
+
+	fix.Contents = contents
+	return true
+}
+
+// FixPermissions aligns executable bits with the launch style signaled by the filename.
+//
+// Scripts with file extensions, and configuration scripts, lose all executable bits.
+// Other scripts gain executable bits wherever the file is readable.
+func FixPermissions(fix *Fix, diagnostic Diagnostic) bool {
+	smell := fix.Analysis.Smell
+
+	if smell.Extension == "" && !smell.CoreConfiguration {
+		fix.Permissions |= 0100 | (fix.Permissions&0444)>>2
+	} else {
+		fix.Permissions &^= 0111
+	}
+
+	return fix.PermissionsChanged()
+}
+
+// Apply runs the available fixers over diagnostics, in order,
+// returning any unresolved diagnostics.
+func (o *Fix) Apply(diagnostics []Diagnostic) []Diagnostic {
+	var unresolved []Diagnostic
+
+	for _, diagnostic := range diagnostics {
+		fixer, ok := Fixers()[diagnostic.Rule]
+
+		if !ok || !fixer(o, diagnostic) {
+			unresolved = append(unresolved, diagnostic)
+			continue
+		}
+
+		o.Fixed = append(o.Fixed, diagnostic)
+	}
+
+	return unresolved
+}
+
+// ContentsChanged reports whether the file contents differ from the original.
+func (o Fix) ContentsChanged() bool {
+	return !bytes.Equal(o.Contents, o.Analysis.Bytes)
+}
+
+// PermissionsChanged reports whether the file permissions differ from the original.
+func (o Fix) PermissionsChanged() bool {
+	return o.Permissions != o.Analysis.Smell.Permissions
+}
+
+// Write saves the rewrites to the original file path.
+//
+// Contents are replaced atomically, by renaming a sibling temporary file over the original.
+// Symlinks are resolved, so that the link survives and the target receives the rewrite.
+// Ownership and non-permission mode bits carry over. Permission-only fixes chmod in place, preserving timestamps.
+func (o Fix) Write() error {
+	pth, err := filepath.EvalSymlinks(o.Analysis.Smell.Path)
+
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(pth)
+
+	if err != nil {
+		return err
+	}
+
+	mode := fi.Mode()&^fs.ModePerm | o.Permissions
+
+	if !o.ContentsChanged() {
+		if !o.PermissionsChanged() {
+			return nil
+		}
+
+		return os.Chmod(pth, mode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(pth), "."+filepath.Base(pth)+".funk-*")
+
+	if err != nil {
+		return err
+	}
+
+	if err := o.writeTemp(tmp, fi, mode); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), pth); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// writeTemp fills a temporary file with the rewritten contents, the original ownership, and mode.
+func (o Fix) writeTemp(tmp *os.File, original fs.FileInfo, mode fs.FileMode) error {
+	if _, err := tmp.Write(o.Contents); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := chownLike(tmp, original); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	return tmp.Close()
+}
@@ -0,0 +1,155 @@
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestFixByteLevelFindings(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "hello.sh")
+
+	if err := os.WriteFile(pth, []byte("\xFE\xFF#!/bin/sh\r\necho hi"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sniffer := stank.NewSniffer()
+	analysis, err := sniffer.Analyze(pth, stank.SniffConfig{EOLCheck: true, CRCheck: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics := []stank.Diagnostic{
+		stank.NewDiagnostic(stank.RuleEOL, pth, "Missing final end of line sequence"),
+		stank.NewDiagnostic(stank.RuleCR, pth, "CR/CRLF line ending detected"),
+		stank.NewDiagnostic(stank.RuleBOM, pth, "Leading BOM reduces portability"),
+		stank.NewDiagnostic(stank.RulePermissions, pth, "Ambiguous launch style. Either feature a file extensions, or else feature executable bits"),
+		stank.NewDiagnostic(stank.RuleSyntax, pth, "sh syntax error"),
+	}
+
+	fix := sniffer.NewFix(analysis)
+	unresolved := fix.Apply(diagnostics)
+
+	if len(unresolved) != 1 || unresolved[0].Rule != stank.RuleSyntax {
+		t.Errorf("expected only the syntax diagnostic to remain, got %v", unresolved)
+	}
+
+	if err := fix.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := os.ReadFile(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "#!/bin/sh\necho hi\n"; string(contents) != expected {
+		t.Errorf("expected %q, got %q", expected, contents)
+	}
+
+	fi, err := os.Stat(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644, got %v", fi.Mode().Perm())
+	}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(pth), ".*funk-*"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 0 {
+		t.Errorf("expected temporary files removed, got %v", matches)
+	}
+}
+
+func TestFixPermissionsGrantsExecutableBits(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	analysis := sniffer.NewAnalysis(stank.Smell{Path: "deploy", POSIXy: true, Permissions: 0640}, []byte("#!/bin/sh\n"))
+	fix := sniffer.NewFix(analysis)
+
+	if !stank.FixPermissions(fix, stank.NewDiagnostic(stank.RuleModulino, "deploy", "")) {
+		t.Errorf("expected permissions fixed")
+	}
+
+	if fix.Permissions != 0750 {
+		t.Errorf("expected mode 0750, got %v", fix.Permissions)
+	}
+}
+
+func TestFixBOMPrefersLongestMarker(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for name, bom := range map[string]string{
+		"UTF-16BE": "\xFE\xFF",
+		"UTF-16LE": "\xFF\xFE",
+		"UTF-32LE": "\xFF\xFE\x00\x00",
+	} {
+		analysis := sniffer.NewAnalysis(stank.Smell{Path: "hello.sh", POSIXy: true, BOM: true}, []byte(bom+"#!/bin/sh\n"))
+		fix := sniffer.NewFix(analysis)
+
+		if !stank.FixBOM(fix, stank.NewDiagnostic(stank.RuleBOM, "hello.sh", "Leading BOM reduces portability")) {
+			t.Errorf("%v: expected the BOM to resolve", name)
+		}
+
+		if expected := "#!/bin/sh\n"; string(fix.Contents) != expected {
+			t.Errorf("%v: expected %q, got %q", name, expected, fix.Contents)
+		}
+	}
+}
+
+func TestFixWritePreservesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "hello.sh")
+	link := filepath.Join(dir, "hello-link.sh")
+
+	if err := os.WriteFile(target, []byte("#!/bin/sh\r\necho hi\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("hello.sh", link); err != nil {
+		t.Skip(err)
+	}
+
+	// Walks following symlinks analyze the target contents under the link path.
+	sniffer := stank.NewSniffer()
+	analysis := sniffer.NewAnalysis(stank.Smell{Path: link, POSIXy: true, Permissions: 0644, ContainsCR: true}, []byte("#!/bin/sh\r\necho hi\r\n"))
+	fix := sniffer.NewFix(analysis)
+
+	if unresolved := fix.Apply([]stank.Diagnostic{stank.NewDiagnostic(stank.RuleCR, link, "CR/CRLF line ending detected")}); len(unresolved) != 0 {
+		t.Fatalf("expected the CR diagnostic to resolve, got %v", unresolved)
+	}
+
+	if err := fix.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Lstat(link)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected the symlink to survive, got mode %v", fi.Mode())
+	}
+
+	contents, err := os.ReadFile(target)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "#!/bin/sh\necho hi\n"; string(contents) != expected {
+		t.Errorf("expected the target rewritten to %q, got %q", expected, contents)
+	}
+}
@@ -0,0 +1,57 @@
+package stank
+
+import (
+	"bytes"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// FormatOptions configures the canonical printing of shell scripts, in the manner of shfmt.
+type FormatOptions struct {
+	// Indent sets the number of spaces per indentation level. Zero indents with tabs.
+	Indent uint `toml:"indent"`
+
+	// BinaryNextLine places binary operators such as && and | at the start of continued lines.
+	BinaryNextLine bool `toml:"binary_next_line"`
+}
+
+// FormatShell prints shell script contents canonically, parsing in the given language variant.
+//
+// Comments, including any shebang, are preserved.
+func FormatShell(name string, contents []byte, variant syntax.LangVariant, options FormatOptions) ([]byte, error) {
+	parser := syntax.NewParser(syntax.Variant(variant), syntax.KeepComments(true))
+	file, err := parser.Parse(bytes.NewReader(contents), name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	printer := syntax.NewPrinter(syntax.Indent(options.Indent), syntax.BinaryNextLine(options.BinaryNextLine))
+
+	if err := printer.Print(&buf, file); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Format prints the script contents canonically, in the language variant of the interpreter.
+func (o Analysis) Format(options FormatOptions) ([]byte, error) {
+	return FormatShell(o.Smell.Path, o.Bytes, o.variant, options)
+}
+
+// FirstDifferingLine locates the 1-based line number where two texts first diverge, or zero when the lines agree.
+//
+// Line endings are disregarded, per SplitLines.
+func FirstDifferingLine(a []byte, b []byte) int {
+	aLines, bLines := SplitLines(a), SplitLines(b)
+
+	for i := 0; i < len(aLines) || i < len(bLines); i++ {
+		if i >= len(aLines) || i >= len(bLines) || aLines[i] != bLines[i] {
+			return i + 1
+		}
+	}
+
+	return 0
+}
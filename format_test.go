@@ -0,0 +1,68 @@
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcandre/stank"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestFormatShell(t *testing.T) {
+	contents := []byte("#!/bin/bash\n# greet\nif [[ -n \"$1\" ]];then\n  echo  hi &&\n  a=(1 2)\nfi\n")
+
+	formatted, err := stank.FormatShell("greet", contents, syntax.LangBash, stank.FormatOptions{Indent: 4, BinaryNextLine: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "#!/bin/bash\n# greet\nif [[ -n \"$1\" ]]; then\n    echo hi \\\n        && a=(1 2)\nfi\n"; string(formatted) != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+
+	if line := stank.FirstDifferingLine(contents, formatted); line != 3 {
+		t.Errorf("expected formatting to diverge at line 3, got %v", line)
+	}
+
+	if _, err := stank.FormatShell("greet", contents, syntax.LangPOSIX, stank.FormatOptions{}); err == nil {
+		t.Errorf("expected bash arrays to fail POSIX formatting")
+	}
+
+	if line := stank.FirstDifferingLine([]byte("echo hi\r\n"), []byte("echo hi\n")); line != 0 {
+		t.Errorf("expected line endings to be disregarded, got %v", line)
+	}
+}
+
+func TestFixFormat(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "greet.zsh")
+
+	if err := os.WriteFile(pth, []byte("#!/bin/zsh\nif true;then\necho ${(U)f}\nfi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sniffer := stank.NewSniffer()
+	analysis, err := sniffer.Analyze(pth, stank.SniffConfig{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fix := sniffer.NewFix(analysis)
+	fix.FormatOptions = stank.FormatOptions{Indent: 2}
+
+	if unresolved := fix.Apply([]stank.Diagnostic{stank.NewDiagnostic(stank.RuleFormat, pth, "Formatting differs from the canonical print")}); len(unresolved) != 0 {
+		t.Fatalf("expected the format diagnostic to resolve, got %v", unresolved)
+	}
+
+	formatted, err := analysis.Format(fix.FormatOptions)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(fix.Contents) != string(formatted) || !fix.ContentsChanged() {
+		t.Errorf("expected canonical zsh contents, got %q", fix.Contents)
+	}
+}
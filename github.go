@@ -0,0 +1,66 @@
+package stank
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubReporter renders diagnostics as GitHub Actions workflow commands,
+// such as ::error file=hello.sh,line=2::message
+type GitHubReporter struct {
+	// Out receives the report.
+	Out io.Writer
+}
+
+// NewGitHubReporter constructs a GitHubReporter.
+func NewGitHubReporter(out io.Writer) *GitHubReporter {
+	return &GitHubReporter{Out: out}
+}
+
+// gitHubDataEscaper escapes workflow command messages.
+var gitHubDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// gitHubPropertyEscaper escapes workflow command properties.
+var gitHubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// GitHubCommand converts a severity to a workflow command name.
+func GitHubCommand(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// Report renders a diagnostic as a workflow command.
+func (o *GitHubReporter) Report(diagnostic Diagnostic) error {
+	properties := []string{"file=" + gitHubPropertyEscaper.Replace(diagnostic.Path)}
+
+	if diagnostic.Line > 0 {
+		properties = append(properties, fmt.Sprintf("line=%d", diagnostic.Line))
+	}
+
+	if diagnostic.Column > 0 {
+		properties = append(properties, fmt.Sprintf("col=%d", diagnostic.Column))
+	}
+
+	properties = append(properties, "title="+gitHubPropertyEscaper.Replace("funk "+diagnostic.Rule))
+
+	_, err := fmt.Fprintf(
+		o.Out,
+		"::%v %v::%v\n",
+		GitHubCommand(diagnostic.Severity),
+		strings.Join(properties, ","),
+		gitHubDataEscaper.Replace(diagnostic.Message),
+	)
+	return err
+}
+
+// Close completes the report.
+func (o *GitHubReporter) Close() error {
+	return nil
+}
@@ -0,0 +1,458 @@
+package stank
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitIgnoreFilename denotes per-directory git exclusion files.
+const GitIgnoreFilename = ".gitignore"
+
+// GitInfoExclude denotes the repository-local git exclusion file, relative to a repository root.
+var GitInfoExclude = filepath.Join(".git", "info", "exclude")
+
+// GitIgnorePattern models a single gitignore rule.
+//
+// See https://git-scm.com/docs/gitignore for the pattern format.
+type GitIgnorePattern struct {
+	// Base denotes the slash separated directory that the pattern is scoped to.
+	// A blank Base applies to every path.
+	Base string
+
+	// Prefix denotes the slash separated path from the declaring .gitignore directory down to Base.
+	// Prefix is non-blank for patterns inherited from directories above the walk root.
+	Prefix string
+
+	// Pattern denotes the original pattern text.
+	Pattern string
+
+	// Negate denotes a re-inclusion (!) rule.
+	Negate bool
+
+	// DirOnly denotes a rule restricted to directories (trailing slash).
+	DirOnly bool
+
+	// re matches paths relative to the declaring directory.
+	re *regexp.Regexp
+}
+
+// ParseGitIgnorePattern compiles a line of a gitignore file.
+//
+// If the line is blank or a comment, ParseGitIgnorePattern returns false.
+func ParseGitIgnorePattern(line string) (GitIgnorePattern, bool) {
+	var pattern GitIgnorePattern
+
+	line = strings.TrimSuffix(line, "\r")
+	line = trimGitIgnoreTrailingSpace(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		pattern.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") && !strings.HasSuffix(line, `\/`) {
+		pattern.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return pattern, false
+	}
+
+	pattern.Pattern = line
+
+	// Patterns with an interior or leading slash anchor to the declaring directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	sb.WriteString(gitIgnoreGlobToRegexp(line))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+
+	if err != nil {
+		return pattern, false
+	}
+
+	pattern.re = re
+	return pattern, true
+}
+
+// trimGitIgnoreTrailingSpace removes unescaped trailing spaces.
+func trimGitIgnoreTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	return line
+}
+
+// gitIgnoreGlobToRegexp translates gitignore wildcards to a regular expression.
+func gitIgnoreGlobToRegexp(glob string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				leading := i == 0 || glob[i-1] == '/'
+				j := i
+
+				for j < len(glob) && glob[j] == '*' {
+					j++
+				}
+
+				trailing := j == len(glob) || glob[j] == '/'
+
+				if leading && trailing {
+					switch {
+					case j == len(glob):
+						// foo/** matches everything inside foo.
+						sb.WriteString(".*")
+					default:
+						// **/ matches zero or more directories.
+						sb.WriteString("(?:.*/)?")
+						j++
+					}
+
+					i = j - 1
+					continue
+				}
+
+				// Other consecutive asterisks are regular asterisks.
+				sb.WriteString("[^/]*")
+				i = j - 1
+				continue
+			}
+
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := gitIgnoreBracketEnd(glob, i)
+
+			if end == -1 {
+				sb.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+
+			sb.WriteString(gitIgnoreBracketToRegexp(glob[i+1 : end]))
+			i = end
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
+
+// gitIgnoreBracketEnd locates the closing bracket of a character class, or -1.
+func gitIgnoreBracketEnd(glob string, start int) int {
+	i := start + 1
+
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		i++
+	}
+
+	// A leading ] is literal.
+	if i < len(glob) && glob[i] == ']' {
+		i++
+	}
+
+	for ; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// gitIgnoreBracketToRegexp translates the body of a character class.
+func gitIgnoreBracketToRegexp(body string) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+
+	if strings.HasPrefix(body, "!") || strings.HasPrefix(body, "^") {
+		sb.WriteString("^/")
+		body = body[1:]
+	}
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+
+		switch c {
+		case '\\':
+			if i+1 < len(body) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(body[i])))
+			}
+		case '[', ']', '^':
+			sb.WriteString(`\`)
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// Match reports whether the pattern applies to a slash separated path.
+func (o GitIgnorePattern) Match(pth string, isDir bool) bool {
+	if o.DirOnly && !isDir {
+		return false
+	}
+
+	rel, ok := gitIgnoreRel(o.Base, pth)
+
+	if !ok {
+		return false
+	}
+
+	if o.Prefix != "" {
+		rel = o.Prefix + "/" + rel
+	}
+
+	return o.re.MatchString(rel)
+}
+
+// gitIgnoreRel computes a slash separated path relative to base.
+func gitIgnoreRel(base string, pth string) (string, bool) {
+	switch {
+	case base == "" || base == ".":
+		return pth, pth != "" && pth != "."
+	case strings.HasSuffix(base, "/"):
+		if strings.HasPrefix(pth, base) && len(pth) > len(base) {
+			return pth[len(base):], true
+		}
+	case strings.HasPrefix(pth, base+"/"):
+		return pth[len(base)+1:], true
+	}
+
+	return "", false
+}
+
+// GitIgnore accumulates gitignore rules, in increasing order of precedence.
+type GitIgnore struct {
+	// Patterns collects rules.
+	Patterns []GitIgnorePattern
+}
+
+// Parse loads gitignore rules from file contents.
+//
+// base scopes the rules to a slash separated directory.
+// prefix denotes the path from the declaring directory to base, if the file lives above base.
+func (o *GitIgnore) Parse(contents []byte, base string, prefix string) {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+
+	for scanner.Scan() {
+		pattern, ok := ParseGitIgnorePattern(scanner.Text())
+
+		if !ok {
+			continue
+		}
+
+		pattern.Base = base
+		pattern.Prefix = prefix
+		o.Patterns = append(o.Patterns, pattern)
+	}
+}
+
+// Match reports whether a slash separated path is excluded,
+// considering only rules that target the path itself.
+//
+// Callers walking a file tree skip excluded directories, and so need not consult parent directories.
+func (o GitIgnore) Match(pth string, isDir bool) bool {
+	for i := len(o.Patterns) - 1; i >= 0; i-- {
+		pattern := o.Patterns[i]
+
+		if pattern.Match(pth, isDir) {
+			return !pattern.Negate
+		}
+	}
+
+	return false
+}
+
+// Ignored reports whether a slash separated path is excluded,
+// either directly or by way of an excluded parent directory.
+func (o GitIgnore) Ignored(pth string, isDir bool) bool {
+	pth = path.Clean(pth)
+	parts := strings.Split(pth, "/")
+
+	for i := 1; i < len(parts); i++ {
+		parent := strings.Join(parts[:i], "/")
+
+		if parent == "" {
+			continue
+		}
+
+		if o.Match(parent, true) {
+			return true
+		}
+	}
+
+	return o.Match(pth, isDir)
+}
+
+// LoadGitIgnoreAncestors collects gitignore rules declared above a directory, within its enclosing git repository.
+//
+// The resulting rules are scoped to dir via Prefix, so that they may be matched against paths relative to dir.
+// If dir does not reside in a git repository, LoadGitIgnoreAncestors returns an empty rule set.
+func LoadGitIgnoreAncestors(dir string) (GitIgnore, error) {
+	var gitIgnore GitIgnore
+
+	abs, err := filepath.Abs(dir)
+
+	if err != nil {
+		return gitIgnore, err
+	}
+
+	ancestors, top := gitAncestors(abs)
+
+	if top == "" {
+		return gitIgnore, nil
+	}
+
+	if contents, err2 := os.ReadFile(filepath.Join(top, GitInfoExclude)); err2 == nil {
+		prefix, err3 := filepath.Rel(top, abs)
+
+		if err3 != nil {
+			return gitIgnore, err3
+		}
+
+		gitIgnore.Parse(contents, "", gitIgnorePrefix(prefix))
+	}
+
+	// Visit ancestors from the repository root downward, excluding dir itself.
+	for i := len(ancestors) - 1; i > 0; i-- {
+		ancestor := ancestors[i]
+		contents, err2 := os.ReadFile(filepath.Join(ancestor, GitIgnoreFilename))
+
+		if err2 != nil {
+			continue
+		}
+
+		prefix, err3 := filepath.Rel(ancestor, abs)
+
+		if err3 != nil {
+			return gitIgnore, err3
+		}
+
+		gitIgnore.Parse(contents, "", gitIgnorePrefix(prefix))
+	}
+
+	return gitIgnore, nil
+}
+
+// GitIgnoredByAncestors reports whether a path is excluded by gitignore rules declared above it,
+// either directly or by way of an excluded parent directory, within its enclosing git repository.
+//
+// If pth does not reside in a git repository, GitIgnoredByAncestors returns false.
+func GitIgnoredByAncestors(pth string, isDir bool) (bool, error) {
+	abs, err := filepath.Abs(pth)
+
+	if err != nil {
+		return false, err
+	}
+
+	ancestors, top := gitAncestors(abs)
+
+	if top == "" {
+		return false, nil
+	}
+
+	var gitIgnore GitIgnore
+
+	if contents, err2 := os.ReadFile(filepath.Join(top, GitInfoExclude)); err2 == nil {
+		gitIgnore.Parse(contents, "", "")
+	}
+
+	// Scope each ancestor's rules to its directory, relative to the repository root.
+	for i := len(ancestors) - 1; i > 0; i-- {
+		ancestor := ancestors[i]
+		contents, err2 := os.ReadFile(filepath.Join(ancestor, GitIgnoreFilename))
+
+		if err2 != nil {
+			continue
+		}
+
+		base, err3 := filepath.Rel(top, ancestor)
+
+		if err3 != nil {
+			return false, err3
+		}
+
+		gitIgnore.Parse(contents, gitIgnorePrefix(base), "")
+	}
+
+	rel, err := filepath.Rel(top, abs)
+
+	if err != nil {
+		return false, err
+	}
+
+	if rel == "." {
+		return false, nil
+	}
+
+	return gitIgnore.Ignored(filepath.ToSlash(rel), isDir), nil
+}
+
+// gitAncestors lists an absolute path and its parent directories, up to the enclosing git repository root.
+// If no repository encloses the path, the root is blank.
+func gitAncestors(abs string) ([]string, string) {
+	var ancestors []string
+
+	for d := abs; ; d = filepath.Dir(d) {
+		ancestors = append(ancestors, d)
+
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return ancestors, d
+		}
+
+		if filepath.Dir(d) == d {
+			return ancestors, ""
+		}
+	}
+}
+
+// gitIgnorePrefix normalizes a relative OS path for use as a pattern prefix.
+func gitIgnorePrefix(rel string) string {
+	if rel == "." {
+		return ""
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,203 @@
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestGitIgnorePatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		pth     string
+		isDir   bool
+		match   bool
+	}{
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+		{"/dist", "dist", true, true},
+		{"/dist", "src/dist", true, false},
+		{"*.log", "a/b/c.log", false, true},
+		{"doc/*.txt", "doc/notes.txt", false, true},
+		{"doc/*.txt", "doc/server/notes.txt", false, false},
+		{"**/cache", "a/b/cache", true, true},
+		{"logs/**", "logs/a/b.txt", false, true},
+		{"a/**/z", "a/z", false, true},
+		{"a/**/z", "a/b/c/z", false, true},
+		{"file?.sh", "file1.sh", false, true},
+		{"file[0-9].sh", "filex.sh", false, false},
+		{"file[!0-9].sh", "filex.sh", false, true},
+		{`\#hash`, "#hash", false, true},
+	}
+
+	for _, c := range cases {
+		pattern, ok := stank.ParseGitIgnorePattern(c.pattern)
+
+		if !ok {
+			t.Errorf("expected pattern %v to parse", c.pattern)
+			continue
+		}
+
+		if got := pattern.Match(c.pth, c.isDir); got != c.match {
+			t.Errorf("expected pattern %v against %v to match %v, got %v", c.pattern, c.pth, c.match, got)
+		}
+	}
+}
+
+func TestGitIgnoreNegation(t *testing.T) {
+	var gitIgnore stank.GitIgnore
+	gitIgnore.Parse([]byte("*.sh\n!keep.sh\n"), "", "")
+
+	if !gitIgnore.Ignored("a/drop.sh", false) {
+		t.Errorf("expected drop.sh to be ignored")
+	}
+
+	if gitIgnore.Ignored("a/keep.sh", false) {
+		t.Errorf("expected keep.sh to be re-included")
+	}
+}
+
+func TestWalkHonorsNestedGitIgnore(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		".gitignore":       "build/\n",
+		"build/out.sh":     "#!/bin/sh\n",
+		"src/.gitignore":   "*.sh\n!main.sh\n",
+		"src/main.sh":      "#!/bin/sh\n",
+		"src/scratch.sh":   "#!/bin/sh\n",
+		"vendor/lib.sh":    "#!/bin/sh\n",
+		"tools/install.sh": "#!/bin/sh\n",
+	}
+
+	for name, contents := range files {
+		pth := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(pth, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var observed []string
+
+	err := stank.Walk(root, stank.NewWalkConfig(), func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filepath.Ext(pth) == ".sh" {
+			rel, err2 := filepath.Rel(root, pth)
+
+			if err2 != nil {
+				return err2
+			}
+
+			observed = append(observed, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"src/main.sh", "tools/install.sh"}
+
+	if !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected walk %v, got %v", expected, observed)
+	}
+}
+
+func TestWalkSkipsRootsIgnoredByAncestors(t *testing.T) {
+	repo := t.TempDir()
+
+	files := map[string]string{
+		".git/info/exclude":    "generated/\n",
+		".gitignore":           "build/\n",
+		"build/out.sh":         "#!/bin/sh\n",
+		"build/nested/deep.sh": "#!/bin/sh\n",
+		"generated/gen.sh":     "#!/bin/sh\n",
+		"src/.gitignore":       "scratch.sh\n",
+		"src/scratch.sh":       "#!/bin/sh\n",
+		"src/main.sh":          "#!/bin/sh\n",
+	}
+
+	for name, contents := range files {
+		pth := filepath.Join(repo, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(pth, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := map[string]bool{
+		"build":          true,
+		"build/nested":   true,
+		"generated":      true,
+		"src/scratch.sh": true,
+		"src":            false,
+		"src/main.sh":    false,
+		".":              false,
+	}
+
+	for name, expected := range testCases {
+		root := filepath.Join(repo, filepath.FromSlash(name))
+		ignored, err := stank.GitIgnoredByAncestors(root, filepath.Ext(root) == "")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ignored != expected {
+			t.Errorf("expected %v to be ignored %v, got %v", name, expected, ignored)
+		}
+
+		var observed []string
+
+		for smell, err2 := range stank.WalkSmells(stank.NewWalkConfig(), root) {
+			if err2 != nil {
+				t.Error(err2)
+			}
+
+			observed = append(observed, smell.Path)
+		}
+
+		if expected && len(observed) != 0 {
+			t.Errorf("expected walking %v to yield nothing, got %v", name, observed)
+		}
+
+		if !expected && len(observed) == 0 {
+			t.Errorf("expected walking %v to yield scripts", name)
+		}
+	}
+
+	config := stank.NewWalkConfig()
+	config.GitIgnore = false
+
+	var observed []string
+
+	for smell, err := range stank.WalkSmells(config, filepath.Join(repo, "build")) {
+		if err != nil {
+			t.Error(err)
+		}
+
+		observed = append(observed, smell.Path)
+	}
+
+	if len(observed) == 0 {
+		t.Errorf("expected disabling gitignore to walk build")
+	}
+}
@@ -0,0 +1,104 @@
+package stank
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// GitLabIssue models a GitLab Code Quality finding.
+type GitLabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    GitLabLocation `json:"location"`
+}
+
+// GitLabLocation models a finding location.
+type GitLabLocation struct {
+	Path  string      `json:"path"`
+	Lines GitLabLines `json:"lines"`
+}
+
+// GitLabLines models a line range.
+type GitLabLines struct {
+	Begin int `json:"begin"`
+}
+
+// GitLabSeverity converts a severity to a Code Quality severity.
+func GitLabSeverity(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "critical"
+	case SeverityWarning:
+		return "major"
+	default:
+		return "info"
+	}
+}
+
+// GitLabReporter renders diagnostics as a GitLab Code Quality JSON array.
+//
+// Diagnostics are buffered until Close.
+type GitLabReporter struct {
+	// Out receives the report.
+	Out io.Writer
+
+	// Issues collects the findings.
+	Issues []GitLabIssue
+
+	// occurrences tallies the issues reported so far, by line independent fingerprint.
+	occurrences map[string]int
+}
+
+// NewGitLabReporter constructs a GitLabReporter.
+func NewGitLabReporter(out io.Writer) *GitLabReporter {
+	return &GitLabReporter{Out: out, Issues: []GitLabIssue{}, occurrences: map[string]int{}}
+}
+
+// Report buffers a diagnostic.
+func (o *GitLabReporter) Report(diagnostic Diagnostic) error {
+	// Code Quality requires a line; whole file findings land on the first line.
+	line := max(diagnostic.Line, 1)
+
+	// Reuse the line independent baseline fingerprint, so that issues survive edits elsewhere in the file.
+	base := diagnostic.Fingerprint
+
+	if base == "" {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%v\x00%v\x00%v", diagnostic.Rule, diagnostic.Path, diagnostic.Message)))
+		base = hex.EncodeToString(sum[:])
+	}
+
+	// GitLab deduplicates issues by fingerprint, so distinguish repeats by occurrence, as baselines count them.
+	if o.occurrences == nil {
+		o.occurrences = map[string]int{}
+	}
+
+	occurrence := o.occurrences[base]
+	o.occurrences[base]++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v\x00%d", base, occurrence)))
+	fingerprint := hex.EncodeToString(sum[:])
+
+	o.Issues = append(o.Issues, GitLabIssue{
+		Description: diagnostic.Message,
+		CheckName:   diagnostic.Rule,
+		Fingerprint: fingerprint,
+		Severity:    GitLabSeverity(diagnostic.Severity),
+		Location: GitLabLocation{
+			Path:  diagnostic.Path,
+			Lines: GitLabLines{Begin: line},
+		},
+	})
+
+	return nil
+}
+
+// Close renders the Code Quality JSON.
+func (o *GitLabReporter) Close() error {
+	encoder := json.NewEncoder(o.Out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(o.Issues)
+}
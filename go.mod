@@ -3,6 +3,7 @@ module github.com/mcandre/stank
 go 1.26.1
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/magefile/mage v1.16.1
 	github.com/mcandre/mx v0.0.47
 	mvdan.cc/sh/v3 v3.13.0
@@ -16,7 +17,6 @@ tool (
 )
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
 	github.com/alexkohler/nakedret/v2 v2.0.6 // indirect
 	github.com/kisielk/errcheck v1.9.0 // indirect
 	golang.org/x/exp/typeparams v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
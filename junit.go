@@ -0,0 +1,79 @@
+package stank
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// JUnitTestSuites models a JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite models a group of test cases.
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase models a single finding as a failed test case.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure models a test case failure.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitReporter renders diagnostics as JUnit XML,
+// with one failed test case per diagnostic, classed by file path.
+//
+// Diagnostics are buffered until Close.
+type JUnitReporter struct {
+	// Out receives the report.
+	Out io.Writer
+
+	// Suite collects the findings.
+	Suite JUnitTestSuite
+}
+
+// NewJUnitReporter constructs a JUnitReporter.
+func NewJUnitReporter(out io.Writer) *JUnitReporter {
+	return &JUnitReporter{Out: out, Suite: JUnitTestSuite{Name: "funk"}}
+}
+
+// Report buffers a diagnostic.
+func (o *JUnitReporter) Report(diagnostic Diagnostic) error {
+	o.Suite.Cases = append(o.Suite.Cases, JUnitTestCase{
+		Name:      diagnostic.Rule,
+		ClassName: diagnostic.Path,
+		Failure: &JUnitFailure{
+			Message: diagnostic.Message,
+			Type:    diagnostic.Severity.String(),
+			Text:    diagnostic.String(),
+		},
+	})
+
+	o.Suite.Tests++
+	o.Suite.Failures++
+	return nil
+}
+
+// Close renders the JUnit XML.
+func (o *JUnitReporter) Close() error {
+	return writeXML(o.Out, JUnitTestSuites{
+		Tests:    o.Suite.Tests,
+		Failures: o.Suite.Failures,
+		Suites:   []JUnitTestSuite{o.Suite},
+	})
+}
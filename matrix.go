@@ -0,0 +1,141 @@
+package stank
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// VerdictAccept denotes a shell accepting a script's syntax.
+	VerdictAccept = "accept"
+
+	// VerdictReject denotes a shell rejecting a script's syntax.
+	VerdictReject = "reject"
+
+	// VerdictMissing denotes a shell absent from PATH.
+	VerdictMissing = "missing"
+)
+
+// MatrixResult records a shell's verdict on a script.
+type MatrixResult struct {
+	// Shell names the shell.
+	Shell string `json:"shell"`
+
+	// Verdict is VerdictAccept, VerdictReject, or VerdictMissing.
+	Verdict string `json:"verdict"`
+
+	// Line is the 1-based line number of any syntax error, or zero when unknown.
+	Line int `json:"line,omitempty"`
+
+	// Message describes any syntax error.
+	Message string `json:"message,omitempty"`
+}
+
+// MatrixRow records the verdicts of several shells on a script.
+type MatrixRow struct {
+	// Path locates the script.
+	Path string `json:"path"`
+
+	// Results holds one verdict per shell, in the requested order.
+	Results []MatrixResult `json:"results"`
+}
+
+// Rejected reports whether any shell rejects the script.
+func (o MatrixRow) Rejected() bool {
+	for _, result := range o.Results {
+		if result.Verdict == VerdictReject {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShellCheckSyntax runs an installed shell's -n check against a script, by way of UnixCheckSyntax.
+//
+// busybox runs as busybox sh -n.
+func ShellCheckSyntax(shell string, smell Smell) error {
+	smell.Interpreter = shell
+
+	if shell == "busybox" {
+		return runSyntaxValidator(smell, shell, "sh", "-n")
+	}
+
+	return UnixCheckSyntax(smell)
+}
+
+// SyntaxMatrix checks a script against each of the given shells.
+//
+// Shells absent from PATH are reported missing, rather than falling back to the in-process parser.
+func SyntaxMatrix(smell Smell, shells []string) MatrixRow {
+	row := MatrixRow{Path: smell.Path}
+
+	for _, shell := range shells {
+		result := MatrixResult{Shell: shell, Verdict: VerdictAccept}
+
+		if _, err := exec.LookPath(shell); err != nil {
+			result.Verdict = VerdictMissing
+		} else if err := ShellCheckSyntax(shell, smell); err != nil {
+			result.Verdict = VerdictReject
+			result.Message = err.Error()
+
+			var interpreterError InterpreterError
+
+			if errors.As(err, &interpreterError) {
+				result.Line = interpreterError.Line
+				result.Message = interpreterError.Message
+			}
+		}
+
+		row.Results = append(row.Results, result)
+	}
+
+	return row
+}
+
+// WriteMatrixTable renders matrix rows as an aligned text table, with one column per shell.
+//
+// Rejections note the line of the syntax error, when known.
+func WriteMatrixTable(w io.Writer, shells []string, rows []MatrixRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintf(tw, "PATH\t%v\n", strings.Join(shells, "\t")); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		cells := []string{row.Path}
+
+		for _, result := range row.Results {
+			cell := result.Verdict
+
+			if result.Line != 0 {
+				cell = fmt.Sprintf("%v:%v", cell, result.Line)
+			}
+
+			cells = append(cells, cell)
+		}
+
+		if _, err := fmt.Fprintln(tw, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// WriteMatrixJSON renders matrix rows as an indented JSON array.
+func WriteMatrixJSON(w io.Writer, rows []MatrixRow) error {
+	if rows == nil {
+		rows = []MatrixRow{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
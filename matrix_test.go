@@ -0,0 +1,72 @@
+package stank_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestSyntaxMatrix(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash unavailable")
+	}
+
+	pth := filepath.Join(t.TempDir(), "arrays")
+
+	if err := os.WriteFile(pth, []byte("#!/bin/sh\na=(1 2)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	row := stank.SyntaxMatrix(stank.Smell{Path: pth, Interpreter: "sh"}, []string{"bash", "no-such-shell"})
+
+	if len(row.Results) != 2 || row.Results[0].Verdict != stank.VerdictAccept || row.Results[1].Verdict != stank.VerdictMissing {
+		t.Errorf("expected bash to accept and no-such-shell to go missing, got %v", row.Results)
+	}
+
+	if row.Rejected() {
+		t.Errorf("expected no rejections")
+	}
+}
+
+func TestWriteMatrix(t *testing.T) {
+	shells := []string{"dash", "bash"}
+	rows := []stank.MatrixRow{
+		{
+			Path: "hello",
+			Results: []stank.MatrixResult{
+				{Shell: "dash", Verdict: stank.VerdictReject, Line: 2, Message: "Syntax error: \"(\" unexpected"},
+				{Shell: "bash", Verdict: stank.VerdictAccept},
+			},
+		},
+	}
+
+	if !rows[0].Rejected() {
+		t.Errorf("expected a rejection")
+	}
+
+	var table strings.Builder
+
+	if err := stank.WriteMatrixTable(&table, shells, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "PATH   dash      bash\nhello  reject:2  accept\n"
+
+	if table.String() != expected {
+		t.Errorf("expected %q, got %q", expected, table.String())
+	}
+
+	var js strings.Builder
+
+	if err := stank.WriteMatrixJSON(&js, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(js.String(), `"verdict": "reject"`) || !strings.Contains(js.String(), `"line": 2`) {
+		t.Errorf("expected JSON verdicts, got %v", js.String())
+	}
+}
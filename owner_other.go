@@ -0,0 +1,13 @@
+//go:build !unix
+
+package stank
+
+import (
+	"io/fs"
+	"os"
+)
+
+// chownLike is a no-op on platforms without POSIX ownership.
+func chownLike(f *os.File, original fs.FileInfo) error {
+	return nil
+}
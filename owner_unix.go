@@ -0,0 +1,31 @@
+//go:build unix
+
+package stank
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// chownLike assigns the owner and group of an original file to a replacement.
+func chownLike(f *os.File, original fs.FileInfo) error {
+	stat, ok := original.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return nil
+	}
+
+	fi, err := f.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	// Skip redundant chowns.
+	if current, ok2 := fi.Sys().(*syscall.Stat_t); ok2 && current.Uid == stat.Uid && current.Gid == stat.Gid {
+		return nil
+	}
+
+	return f.Chown(int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestFixWritePreservesOwnership(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "hello.sh")
+
+	if err := os.WriteFile(pth, []byte("#!/bin/sh\r\necho hi\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only privileged users may hand files to other owners.
+	if err := os.Chown(pth, 1, 1); err != nil {
+		t.Skip(err)
+	}
+
+	sniffer := stank.NewSniffer()
+	analysis := sniffer.NewAnalysis(stank.Smell{Path: pth, POSIXy: true, Permissions: 0644, ContainsCR: true}, []byte("#!/bin/sh\r\necho hi\r\n"))
+	fix := sniffer.NewFix(analysis)
+
+	if unresolved := fix.Apply([]stank.Diagnostic{stank.NewDiagnostic(stank.RuleCR, pth, "CR/CRLF line ending detected")}); len(unresolved) != 0 {
+		t.Fatalf("expected the CR diagnostic to resolve, got %v", unresolved)
+	}
+
+	if err := fix.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(pth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok && (stat.Uid != 1 || stat.Gid != 1) {
+		t.Errorf("expected ownership 1:1 to carry over, got %v:%v", stat.Uid, stat.Gid)
+	}
+}
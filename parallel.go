@@ -0,0 +1,74 @@
+package stank
+
+import (
+	"iter"
+	"sync"
+)
+
+// ParallelMap applies fn to each pair of seq, using up to jobs concurrent workers.
+//
+// Results are yielded in the same order as the input pairs,
+// so that output remains identical to a serial run.
+// A jobs count of one or less maps serially.
+//
+// seq is consumed from a separate goroutine, and fn must be safe for concurrent use.
+func ParallelMap[K, V, U any](jobs int, seq iter.Seq2[K, V], fn func(K, V) U) iter.Seq[U] {
+	if jobs <= 1 {
+		return func(yield func(U) bool) {
+			for k, v := range seq {
+				if !yield(fn(k, v)) {
+					return
+				}
+			}
+		}
+	}
+
+	return func(yield func(U) bool) {
+		// pending queues result channels in input order.
+		pending := make(chan chan U, jobs)
+		slots := make(chan struct{}, jobs)
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer close(pending)
+
+			for k, v := range seq {
+				select {
+				case slots <- struct{}{}:
+				case <-done:
+					return
+				}
+
+				result := make(chan U, 1)
+
+				select {
+				case pending <- result:
+				case <-done:
+					<-slots
+					return
+				}
+
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+					result <- fn(k, v)
+					<-slots
+				}()
+			}
+		}()
+
+		defer wg.Wait()
+		defer close(done)
+
+		for result := range pending {
+			if !yield(<-result) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package stank_test
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcandre/stank"
+)
+
+func TestParallelMapPreservesOrder(t *testing.T) {
+	var numbers iter.Seq2[int, error] = func(yield func(int, error) bool) {
+		for i := range 100 {
+			if !yield(i, nil) {
+				return
+			}
+		}
+	}
+
+	square := func(i int, _ error) int {
+		// Finish later inputs sooner, to shuffle completion order.
+		time.Sleep(time.Duration(100-i) * time.Microsecond)
+		return i * i
+	}
+
+	for _, jobs := range []int{0, 1, 8} {
+		var observed []int
+
+		for n := range stank.ParallelMap(jobs, numbers, square) {
+			observed = append(observed, n)
+
+			if len(observed) == 50 {
+				break
+			}
+		}
+
+		var expected []int
+
+		for i := range 50 {
+			expected = append(expected, i*i)
+		}
+
+		if !reflect.DeepEqual(observed, expected) {
+			t.Errorf("jobs %v: expected %v, got %v", jobs, expected, observed)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package stank
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// DefaultShebangPrefix precedes the interpreter name in canonical shebangs.
+const DefaultShebangPrefix = "#!/usr/bin/env "
+
+// lineEnding selects CRLF for contents already featuring CRLF line endings, and LF otherwise.
+func lineEnding(contents []byte) string {
+	if bytes.Contains(contents, []byte("\r\n")) {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// splitFirstLine separates the first line of contents from the line ending and remainder.
+func splitFirstLine(contents []byte) (line string, rest []byte) {
+	index := bytes.IndexByte(contents, '\n')
+
+	if index == -1 {
+		return strings.TrimSuffix(string(contents), "\r"), nil
+	}
+
+	return strings.TrimSuffix(string(contents[:index]), "\r"), contents[index+1:]
+}
+
+// insertLine adds a line ahead of the given 0-based line index.
+func insertLine(contents []byte, index int, line string) []byte {
+	eol := lineEnding(contents)
+	offset := 0
+
+	for i := 0; i < index; i++ {
+		next := bytes.IndexByte(contents[offset:], '\n')
+
+		if next == -1 {
+			offset = len(contents)
+			break
+		}
+
+		offset += next + 1
+	}
+
+	var buf bytes.Buffer
+	buf.Write(contents[:offset])
+
+	if offset != 0 && !bytes.HasSuffix(contents[:offset], []byte("\n")) {
+		buf.WriteString(eol)
+	}
+
+	buf.WriteString(line)
+	buf.WriteString(eol)
+	buf.Write(contents[offset:])
+	return buf.Bytes()
+}
+
+// commandName renders the literal name of a simple command, or a blank string.
+func commandName(stmt *syntax.Stmt) string {
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+
+	if !ok || len(call.Args) == 0 {
+		return ""
+	}
+
+	return call.Args[0].Lit()
+}
+
+// IsIFSReset reports whether a statement resets IFS,
+// as with `unset IFS`, `IFS=...`, or `export IFS=...`.
+func IsIFSReset(stmt *syntax.Stmt) bool {
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		if len(cmd.Args) == 0 {
+			for _, assign := range cmd.Assigns {
+				if assign.Name != nil && assign.Name.Value == "IFS" {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if cmd.Args[0].Lit() != "unset" {
+			return false
+		}
+
+		for _, arg := range cmd.Args[1:] {
+			if arg.Lit() == "IFS" {
+				return true
+			}
+		}
+	case *syntax.DeclClause:
+		for _, assign := range cmd.Args {
+			if assign.Name != nil && assign.Name.Value == "IFS" && !assign.Naked {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MissingIFSReset locates the first top level statement preceding any IFS reset,
+// skipping set and unset commands.
+//
+// Scripts which reset IFS ahead of other commands, or which lack other commands, yield nil.
+func MissingIFSReset(file *syntax.File) *syntax.Stmt {
+	for _, stmt := range file.Stmts {
+		if IsIFSReset(stmt) {
+			return nil
+		}
+
+		if name := commandName(stmt); name == "set" || name == "unset" {
+			continue
+		}
+
+		return stmt
+	}
+
+	return nil
+}
+
+// MissingSafetyFlags locates the first top level statement preceding any set command,
+// skipping IFS resets and unset commands.
+//
+// Scripts which set flags ahead of other commands, or which lack other commands, yield nil.
+func MissingSafetyFlags(file *syntax.File) *syntax.Stmt {
+	for _, stmt := range file.Stmts {
+		name := commandName(stmt)
+
+		if name == "set" {
+			return nil
+		}
+
+		if IsIFSReset(stmt) || name == "unset" {
+			continue
+		}
+
+		return stmt
+	}
+
+	return nil
+}
+
+// HasErrTraceFlag reports whether set arguments enable GNU bash -E / -o errtrace.
+//
+// Arguments following -- or the first operand are positional parameters, rather than flags.
+func HasErrTraceFlag(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" || arg == "-" || (!strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "+")) {
+			return false
+		}
+
+		enable := arg[0] == '-'
+
+		if strings.Contains(arg, "o") && i+1 < len(args) {
+			i++
+
+			if enable && args[i] == "errtrace" {
+				return true
+			}
+		}
+
+		if enable && strings.Contains(arg, "E") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setFlagLetters lists the single letter options accepted by set, per dialect.
+func (o Sniffer) setFlagLetters(interpreter string) string {
+	if o.FullBashInterpreters[interpreter] {
+		return "abefhkmnptuvxBCEHPT"
+	}
+
+	return "abCefhmnuvx"
+}
+
+// setFlags validates interpreter flags as arguments to set,
+// such as -euo pipefail.
+func (o Sniffer) setFlags(interpreter string, flags []string) bool {
+	letters := o.setFlagLetters(interpreter)
+	expectOption := false
+
+	for _, flag := range flags {
+		if expectOption {
+			expectOption = false
+			continue
+		}
+
+		if len(flag) < 2 || (flag[0] != '-' && flag[0] != '+') {
+			return false
+		}
+
+		for _, c := range flag[1:] {
+			switch {
+			case c == 'o':
+				expectOption = true
+			case !strings.ContainsRune(letters, c):
+				return false
+			}
+		}
+	}
+
+	return !expectOption
+}
+
+// SafetyFlagsCommand renders a set command with safety flags for the script dialect.
+func (o Sniffer) SafetyFlagsCommand(interpreter string) string {
+	switch {
+	case o.FullBashInterpreters[interpreter]:
+		// errtrace additionally guards traps.
+		return "set -Eeuf"
+	case interpreter == "zsh":
+		// zsh spells noglob as -F.
+		return "set -euF"
+	default:
+		return "set -euf"
+	}
+}
+
+// IFSResetCommand renders a command resetting IFS for the script dialect.
+func (o Sniffer) IFSResetCommand(interpreter string) string {
+	if interpreter == "zsh" {
+		return `IFS=$' \t\n\0'`
+	}
+
+	return "unset IFS"
+}
+
+// FixShebang repairs the shebang line.
+//
+// Flipped !# shebangs flip to #!. Nested #!#! shebangs and trailing comments are trimmed.
+// Relative interpreters adopt Fix.ShebangPrefix, as do missing shebangs, given a known interpreter.
+// Interpreter flags move to a set command on the following line, for POSIXy scripts,
+// including flags split out by env -S.
+func FixShebang(fix *Fix, diagnostic Diagnostic) bool {
+	smell := fix.Analysis.Smell
+	line, rest := splitFirstLine(fix.Contents)
+	eol := lineEnding(fix.Contents)
+
+	if strings.HasPrefix(line, "!#") {
+		line = "#!" + line[2:]
+	}
+
+	if !strings.HasPrefix(line, "#!") {
+		if !smell.POSIXy || smell.Interpreter == "" {
+			return false
+		}
+
+		interpreter := smell.Interpreter
+
+		if interpreter == "generic-sh" {
+			interpreter = "sh"
+		}
+
+		fix.Contents = append([]byte(fix.ShebangPrefix+interpreter+eol), fix.Contents...)
+		return true
+	}
+
+	command := line
+
+	for strings.HasPrefix(command, "#!") {
+		command = strings.TrimSpace(command[2:])
+	}
+
+	if index := strings.Index(command, "#"); index != -1 {
+		command = strings.TrimSpace(command[:index])
+	}
+
+	parts := strings.Fields(command)
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	// env -S splits the interpreter from its flags, which the set command takes over.
+	if parts[0] == "/usr/bin/env" && len(parts) > 2 && IsEnvSplitString(parts[1]) {
+		parts = slices.Delete(parts, 1, 2)
+	}
+
+	head := parts[:1]
+
+	if (parts[0] == "/usr/bin/env" || parts[0] == "/bin/busybox") && len(parts) > 1 {
+		head = parts[:2]
+	}
+
+	flags := parts[len(head):]
+
+	shebang := "#!" + strings.Join(head, " ")
+
+	if !filepath.IsAbs(head[0]) {
+		shebang = fix.ShebangPrefix + filepath.Base(head[0])
+	}
+
+	var setCommand string
+
+	if len(flags) != 0 {
+		if !smell.POSIXy || !fix.Sniffer.setFlags(smell.Interpreter, flags) {
+			return false
+		}
+
+		setCommand = "set " + strings.Join(flags, " ") + eol
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(shebang)
+	buf.WriteString(eol)
+	buf.WriteString(setCommand)
+	buf.Write(rest)
+	fix.Contents = buf.Bytes()
+	return true
+}
+
+// executablePrologue reports whether an executable script prologue applies,
+// resolving the finding for scripts which earlier permission fixes turned into libraries.
+//
+// Configuration scripts never receive a prologue, as safety flags would disrupt interactive shells.
+func (o Fix) executablePrologue() (applies bool, resolved bool) {
+	smell := o.Analysis.Smell
+
+	switch {
+	case smell.CoreConfiguration:
+		return false, false
+	case smell.Extension != "" && o.Permissions&0100 == 0:
+		return false, true
+	default:
+		return true, false
+	}
+}
+
+// parse parses the rewritten contents, in the language variant of the interpreter.
+func (o Fix) parse() (*syntax.File, error) {
+	parser := syntax.NewParser(syntax.Variant(o.Sniffer.Variant(o.Analysis.Smell.Interpreter)))
+	return parser.Parse(bytes.NewReader(o.Contents), o.Analysis.Smell.Path)
+}
+
+// insertPrologueCommand inserts a command line ahead of the statement located by missing,
+// reporting whether missing locates no statement afterward.
+//
+// Failed insertions leave the contents untouched.
+func (o *Fix) insertPrologueCommand(command string, missing func(*syntax.File) *syntax.Stmt, at func(*syntax.File) *syntax.Stmt) bool {
+	file, err := o.parse()
+
+	if err != nil {
+		return false
+	}
+
+	if missing(file) == nil {
+		return true
+	}
+
+	original := o.Contents
+	o.Contents = insertLine(o.Contents, int(at(file).Pos().Line())-1, command)
+
+	if file, err = o.parse(); err != nil || missing(file) != nil {
+		o.Contents = original
+		return false
+	}
+
+	return true
+}
+
+// FixIFSReset inserts an IFS reset ahead of the first top level statement, after the shebang and leading comments.
+func FixIFSReset(fix *Fix, diagnostic Diagnostic) bool {
+	if applies, resolved := fix.executablePrologue(); !applies {
+		return resolved
+	}
+
+	return fix.insertPrologueCommand(fix.Sniffer.IFSResetCommand(fix.Analysis.Smell.Interpreter), MissingIFSReset, func(file *syntax.File) *syntax.Stmt {
+		return file.Stmts[0]
+	})
+}
+
+// FixSafetyFlags inserts a set command ahead of the first top level statement
+// other than IFS resets and unset commands.
+func FixSafetyFlags(fix *Fix, diagnostic Diagnostic) bool {
+	if applies, resolved := fix.executablePrologue(); !applies {
+		return resolved
+	}
+
+	return fix.insertPrologueCommand(fix.Sniffer.SafetyFlagsCommand(fix.Analysis.Smell.Interpreter), MissingSafetyFlags, MissingSafetyFlags)
+}
@@ -0,0 +1,195 @@
+package stank_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mcandre/stank"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestFixShebang(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		smell    stank.Smell
+		contents string
+		expected string
+	}{
+		{stank.Smell{POSIXy: true, Interpreter: "sh"}, "!#/bin/sh\necho hi\n", "#!/bin/sh\necho hi\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "sh"}, "#!#!/bin/sh\necho hi\n", "#!/bin/sh\necho hi\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "bash"}, "#!bash\necho hi\n", "#!/usr/bin/env bash\necho hi\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "bash"}, "#!/bin/bash -euo pipefail\r\necho hi\r\n", "#!/bin/bash\r\nset -euo pipefail\r\necho hi\r\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "bash", Extension: ".bash"}, "echo hi\n", "#!/usr/bin/env bash\necho hi\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "bash"}, "#!/usr/bin/env -S bash -e -u\necho hi\n", "#!/usr/bin/env bash\nset -e -u\necho hi\n"},
+		{stank.Smell{POSIXy: true, Interpreter: "bash"}, "#!/usr/bin/env --split-string bash\necho hi\n", "#!/usr/bin/env bash\necho hi\n"},
+	} {
+		fix := sniffer.NewFix(sniffer.NewAnalysis(tc.smell, []byte(tc.contents)))
+
+		if !stank.FixShebang(fix, stank.Diagnostic{Rule: stank.RuleShebang}) {
+			t.Errorf("expected %q fixed", tc.contents)
+		}
+
+		if string(fix.Contents) != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, fix.Contents)
+		}
+	}
+}
+
+func TestSniffEnvSplitString(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, shebang := range []string{"#!/usr/bin/env -S bash -e", "#!/usr/bin/env --split-string bash -e"} {
+		smell, err := sniffer.SniffBytes("deploy", 0755, []byte(shebang+"\necho hi\n"), stank.SniffConfig{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if smell.Interpreter != "bash" || !smell.POSIXy || !slices.Equal(smell.InterpreterFlags, []string{"-e"}) {
+			t.Errorf("expected POSIXy bash with flags [-e] for %q, got %v %v %v", shebang, smell.Interpreter, smell.POSIXy, smell.InterpreterFlags)
+		}
+	}
+}
+
+func TestFixShebangRetainsUnmovableFlags(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	contents := "#!/bin/bash --posix\necho hi\n"
+	fix := sniffer.NewFix(sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: "bash"}, []byte(contents)))
+
+	if stank.FixShebang(fix, stank.Diagnostic{Rule: stank.RuleShebang}) || string(fix.Contents) != contents {
+		t.Errorf("expected --posix to remain in the shebang, got %q", fix.Contents)
+	}
+}
+
+func TestFixPrologue(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		smell    stank.Smell
+		contents string
+		expected string
+	}{
+		{
+			stank.Smell{POSIXy: true, Interpreter: "sh", Permissions: 0755},
+			"#!/bin/sh\n# Greet\n\necho hi\n",
+			"#!/bin/sh\n# Greet\n\nunset IFS\nset -euf\necho hi\n",
+		},
+		{
+			stank.Smell{POSIXy: true, Interpreter: "bash", Permissions: 0755},
+			"#!/bin/bash\nset -x\necho hi\n",
+			"#!/bin/bash\nunset IFS\nset -x\necho hi\n",
+		},
+		{
+			stank.Smell{POSIXy: true, Interpreter: "zsh", Permissions: 0755},
+			"#!/bin/zsh\nIFS=:\necho hi\n",
+			"#!/bin/zsh\nIFS=:\nset -euF\necho hi\n",
+		},
+		{
+			stank.Smell{POSIXy: true, Interpreter: "sh", Permissions: 0755},
+			"#!/bin/sh\nsetup_env\n",
+			"#!/bin/sh\nunset IFS\nset -euf\nsetup_env\n",
+		},
+		{
+			stank.Smell{POSIXy: true, Interpreter: "sh", Permissions: 0755},
+			"#!/bin/sh\nsettings=1\nIFSX=2\nunsetenv\n",
+			"#!/bin/sh\nunset IFS\nset -euf\nsettings=1\nIFSX=2\nunsetenv\n",
+		},
+		{
+			stank.Smell{POSIXy: true, Interpreter: "sh", Permissions: 0755, CoreConfiguration: true},
+			"echo hi\n",
+			"echo hi\n",
+		},
+	} {
+		fix := sniffer.NewFix(sniffer.NewAnalysis(tc.smell, []byte(tc.contents)))
+		stank.FixIFSReset(fix, stank.Diagnostic{Rule: stank.RuleIFSReset})
+		stank.FixSafetyFlags(fix, stank.Diagnostic{Rule: stank.RuleSafetyFlags})
+
+		if string(fix.Contents) != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, fix.Contents)
+		}
+	}
+}
+
+func TestFixPrologueReportsUnresolvedFindings(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	contents := "#!/bin/sh\nif true; then\n"
+	fix := sniffer.NewFix(sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: "sh", Permissions: 0755}, []byte(contents)))
+
+	if stank.FixIFSReset(fix, stank.Diagnostic{Rule: stank.RuleIFSReset}) || stank.FixSafetyFlags(fix, stank.Diagnostic{Rule: stank.RuleSafetyFlags}) {
+		t.Errorf("expected unparseable scripts to remain unfixed")
+	}
+
+	if string(fix.Contents) != contents {
+		t.Errorf("expected %q, got %q", contents, fix.Contents)
+	}
+}
+
+func TestMissingPrologueCommands(t *testing.T) {
+	sniffer := stank.NewSniffer()
+
+	for _, tc := range []struct {
+		name        string
+		interpreter string
+		contents    string
+		ifsReset    [2]uint
+		safetyFlags [2]uint
+	}{
+		{"prologue", "sh", "#!/bin/sh\nset -euf\nunset IFS\necho hi\n", [2]uint{}, [2]uint{}},
+		{"heredoc", "sh", "#!/bin/sh\ncat <<EOF\nset -euf\nunset IFS\nEOF\n", [2]uint{2, 1}, [2]uint{2, 1}},
+		{"quoted string", "sh", "#!/bin/sh\necho 'set -euf; unset IFS'\n", [2]uint{2, 1}, [2]uint{2, 1}},
+		{"line continuation", "sh", "#!/bin/sh\nunset \\\n  IFS\nset \\\n  -euf\necho hi\n", [2]uint{}, [2]uint{}},
+		{"lookalike commands", "sh", "#!/bin/sh\nsetup_env\nsettings=1\nIFSX=2\nunsetenv\n", [2]uint{2, 1}, [2]uint{2, 1}},
+		{"export", "bash", "#!/bin/bash\nexport IFS=$'\\n\\t'\nset -e\necho hi\n", [2]uint{}, [2]uint{}},
+		{"same line", "sh", "#!/bin/sh\nset -e; echo hi\n", [2]uint{2, 9}, [2]uint{}},
+		{"comment", "sh", "#!/bin/sh\n# set -euf\necho hi\n", [2]uint{3, 1}, [2]uint{3, 1}},
+	} {
+		analysis := sniffer.NewAnalysis(stank.Smell{POSIXy: true, Interpreter: tc.interpreter}, []byte(tc.contents))
+		file, err := analysis.File()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, check := range []struct {
+			rule     string
+			stmt     *syntax.Stmt
+			expected [2]uint
+		}{
+			{stank.RuleIFSReset, stank.MissingIFSReset(file), tc.ifsReset},
+			{stank.RuleSafetyFlags, stank.MissingSafetyFlags(file), tc.safetyFlags},
+		} {
+			var observed [2]uint
+
+			if check.stmt != nil {
+				observed = [2]uint{check.stmt.Pos().Line(), check.stmt.Pos().Col()}
+			}
+
+			if observed != check.expected {
+				t.Errorf("%v: expected %v at %v, got %v", tc.name, check.rule, check.expected, observed)
+			}
+		}
+	}
+}
+
+func TestHasErrTraceFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"-E"}, true},
+		{[]string{"-eEu"}, true},
+		{[]string{"-o", "errtrace"}, true},
+		{[]string{"-euo", "errtrace"}, true},
+		{[]string{"-e"}, false},
+		{[]string{"+E"}, false},
+		{[]string{"-o", "pipefail"}, false},
+		{[]string{"-o", "pipefail", "-E"}, true},
+		{[]string{"--", "-E"}, false},
+		{[]string{"hello", "-E"}, false},
+	} {
+		if observed := stank.HasErrTraceFlag(tc.args); observed != tc.expected {
+			t.Errorf("expected %v for %q, got %v", tc.expected, tc.args, observed)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package stank
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Reporter renders diagnostics.
+//
+// Close flushes any buffered output, for formats which render the whole report at once.
+type Reporter interface {
+	// Report renders a diagnostic.
+	Report(Diagnostic) error
+
+	// Close completes the report.
+	Close() error
+}
+
+// TextReporter renders diagnostics as lines of text.
+type TextReporter struct {
+	// Out receives the report.
+	Out io.Writer
+}
+
+// NewTextReporter constructs a TextReporter.
+func NewTextReporter(out io.Writer) *TextReporter {
+	return &TextReporter{Out: out}
+}
+
+// Report renders a diagnostic as a line of text.
+func (o *TextReporter) Report(diagnostic Diagnostic) error {
+	_, err := fmt.Fprintln(o.Out, diagnostic)
+	return err
+}
+
+// Close completes the report.
+func (o *TextReporter) Close() error {
+	return nil
+}
+
+// ReporterFactory constructs a Reporter writing to out.
+type ReporterFactory func(out io.Writer) Reporter
+
+// reporterRegistry guards the registered report formats.
+var reporterRegistry = struct {
+	sync.RWMutex
+	factories map[string]ReporterFactory
+}{
+	factories: map[string]ReporterFactory{
+		"checkstyle": func(out io.Writer) Reporter { return NewCheckstyleReporter(out) },
+		"github":     func(out io.Writer) Reporter { return NewGitHubReporter(out) },
+		"gitlab":     func(out io.Writer) Reporter { return NewGitLabReporter(out) },
+		"junit":      func(out io.Writer) Reporter { return NewJUnitReporter(out) },
+		"sarif":      func(out io.Writer) Reporter { return NewSARIFReporter(out) },
+		"text":       func(out io.Writer) Reporter { return NewTextReporter(out) },
+	},
+}
+
+// RegisterReporter adds or replaces a report format, for selection with NewReporter.
+func RegisterReporter(format string, factory ReporterFactory) {
+	reporterRegistry.Lock()
+	defer reporterRegistry.Unlock()
+	reporterRegistry.factories[format] = factory
+}
+
+// ReporterFormats lists the registered report formats, in lexicographic order.
+func ReporterFormats() []string {
+	reporterRegistry.RLock()
+	defer reporterRegistry.RUnlock()
+	return slices.Sorted(maps.Keys(reporterRegistry.factories))
+}
+
+// NewReporter constructs a Reporter for a registered report format.
+func NewReporter(format string, out io.Writer) (Reporter, error) {
+	reporterRegistry.RLock()
+	factory, ok := reporterRegistry.factories[format]
+	reporterRegistry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown format: %v", format)
+	}
+
+	return factory(out), nil
+}
@@ -0,0 +1,143 @@
+package stank_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+// countingReporter tallies diagnostics.
+type countingReporter struct {
+	count int
+}
+
+func (o *countingReporter) Report(stank.Diagnostic) error {
+	o.count++
+	return nil
+}
+
+func (o *countingReporter) Close() error {
+	return nil
+}
+
+func TestRegisterReporter(t *testing.T) {
+	counter := &countingReporter{}
+	stank.RegisterReporter("count", func(io.Writer) stank.Reporter { return counter })
+
+	if !slices.Contains(stank.ReporterFormats(), "count") {
+		t.Errorf("expected registered format, got %v", stank.ReporterFormats())
+	}
+
+	reporter, err := stank.NewReporter("count", io.Discard)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reporter.Report(stank.NewDiagnostic(stank.RuleBOM, "hello.sh", "Leading BOM reduces portability")); err != nil {
+		t.Fatal(err)
+	}
+
+	if counter.count != 1 {
+		t.Errorf("expected custom reporter to receive diagnostic")
+	}
+
+	if _, err := stank.NewReporter("carrier-pigeon", io.Discard); err == nil {
+		t.Errorf("expected unknown format error")
+	}
+}
+
+func TestGitHubReporterEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := stank.NewGitHubReporter(&buf)
+
+	diagnostic := stank.NewDiagnostic(stank.RuleSyntax, "a,b:c.sh", "100% broken\nbadly")
+	diagnostic.Line = 3
+
+	if err := reporter.Report(diagnostic); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "::error file=a%2Cb%3Ac.sh,line=3,title=funk syntax::100%25 broken%0Abadly\n"
+
+	if observed := buf.String(); observed != expected {
+		t.Errorf("expected %q, got %q", expected, observed)
+	}
+}
+
+func TestCheckstyleReporterGroupsFiles(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := stank.NewCheckstyleReporter(&buf)
+
+	for _, pth := range []string{"a.sh", "a.sh", "b.sh"} {
+		if err := reporter.Report(stank.NewDiagnostic(stank.RuleShebang, pth, "Missing shebang")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var result stank.CheckstyleResult
+
+	if err := xml.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Files) != 2 || len(result.Files[0].Errors) != 2 || result.Files[1].Errors[0].Source != "funk.shebang" {
+		t.Errorf("expected findings grouped by file, got %v", result)
+	}
+}
+
+func TestGitLabReporterFingerprints(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	smell := stank.Smell{Path: "run", POSIXy: true}
+	before := sniffer.NewAnalysis(smell, []byte("#!/bin/sh\nexec true\nexec true\n"))
+	after := sniffer.NewAnalysis(smell, []byte("#!/bin/sh\n\necho hi\nexec true\nexec true\n"))
+
+	report := func(analysis stank.Analysis, lines ...int) []stank.GitLabIssue {
+		reporter := stank.NewGitLabReporter(io.Discard)
+
+		for _, line := range lines {
+			diagnostic := stank.NewDiagnostic(stank.RuleTrapHazards, "run", "exec discards traps")
+			diagnostic.Line = line
+			diagnostic.Fingerprint = analysis.Fingerprint(diagnostic)
+
+			if err := reporter.Report(diagnostic); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for range 2 {
+			if err := reporter.Report(stank.NewDiagnostic(stank.RuleBOM, "run", "Leading BOM reduces portability")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return reporter.Issues
+	}
+
+	beforeIssues, afterIssues := report(before, 2, 3), report(after, 4, 5)
+	seen := map[string]bool{}
+
+	for i, issue := range beforeIssues {
+		if seen[issue.Fingerprint] {
+			t.Errorf("expected unique fingerprints for identical findings, got %v", beforeIssues)
+		}
+
+		seen[issue.Fingerprint] = true
+
+		if afterIssues[i].Fingerprint != issue.Fingerprint {
+			t.Errorf("expected fingerprints to survive line shifts, got %v and %v", beforeIssues, afterIssues)
+		}
+	}
+
+	if afterIssues[0].Location.Lines.Begin != 4 || afterIssues[2].Location.Lines.Begin != 1 {
+		t.Errorf("expected issue locations to retain lines, got %v", afterIssues)
+	}
+}
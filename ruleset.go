@@ -0,0 +1,277 @@
+package stank
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultProfile names the profile applied when none is configured.
+const DefaultProfile = "default"
+
+// Profile bundles a named selection of rules.
+type Profile struct {
+	// Rules lists the enabled rule IDs.
+	Rules []string
+
+	// Severities overrides default rule severities.
+	Severities map[string]Severity
+}
+
+// optInRules lists the rules omitted from the default profile:
+// opinionated rules, and rules which would newly fail existing, clean projects.
+var optInRules = []string{RuleAltSyntax, RuleBashisms, RuleDialect, RuleFormat, RuleInterpreterMismatch, RuleModulino, RuleUnusedSuppression}
+
+// Profiles catalogs the named rule selections.
+//
+// default enables every rule, except the opt-in rules.
+// strict enables every rule, escalating findings to errors.
+// portable focuses on encoding, line ending, shebang, syntax, and dialect hazards across platforms.
+// security focuses on tokenization, error handling, and trap hazards.
+// legacy enables the classic funk checks.
+var Profiles = sync.OnceValue(func() map[string]Profile {
+	var all []string
+	strictSeverities := map[string]Severity{}
+
+	for id := range Rules() {
+		all = append(all, id)
+		strictSeverities[id] = SeverityError
+	}
+
+	slices.Sort(all)
+
+	return map[string]Profile{
+		DefaultProfile: {
+			Rules: slices.DeleteFunc(slices.Clone(all), func(id string) bool {
+				return slices.Contains(optInRules, id)
+			}),
+		},
+		"strict": {
+			Rules:      all,
+			Severities: strictSeverities,
+		},
+		"portable": {
+			Rules: []string{RuleAltSyntax, RuleBashisms, RuleBOM, RuleCR, RuleDialect, RuleEOL, RuleInterpreter, RuleInterpreterMismatch, RulePermissions, RuleShebang, RuleSyntax},
+		},
+		"security": {
+			Rules: []string{RuleIFSReset, RuleSafetyFlags, RuleShebang, RuleSyntax, RuleTrapHazards},
+			Severities: map[string]Severity{
+				RuleIFSReset:    SeverityError,
+				RuleSafetyFlags: SeverityError,
+				RuleTrapHazards: SeverityError,
+			},
+		},
+		"legacy": {
+			Rules: []string{RuleBOM, RuleCR, RuleEOL, RuleIFSReset, RuleInterpreter, RulePermissions, RuleSafetyFlags, RuleShebang, RuleSyntax, RuleTrapHazards},
+		},
+	}
+})
+
+// RuleSet selects which rules apply, and at what severity.
+type RuleSet struct {
+	// Enabled denotes active rule IDs.
+	Enabled map[string]bool
+
+	// Severities overrides default rule severities.
+	Severities map[string]Severity
+}
+
+// NewRuleSet constructs a RuleSet from the default profile.
+func NewRuleSet() RuleSet {
+	var ruleSet RuleSet
+	ruleSet.useProfile(Profiles()[DefaultProfile])
+	return ruleSet
+}
+
+// checkRule validates a rule ID.
+func checkRule(id string) error {
+	if _, ok := Rules()[id]; !ok {
+		return fmt.Errorf("unknown rule: %v", id)
+	}
+
+	return nil
+}
+
+// ApplyProfile replaces the rule selection with a named profile.
+func (o *RuleSet) ApplyProfile(name string) error {
+	profile, ok := Profiles()[name]
+
+	if !ok {
+		return fmt.Errorf("unknown profile: %v", name)
+	}
+
+	o.useProfile(profile)
+	return nil
+}
+
+// useProfile implements ApplyProfile.
+func (o *RuleSet) useProfile(profile Profile) {
+	o.Enabled = map[string]bool{}
+
+	for _, id := range profile.Rules {
+		o.Enabled[id] = true
+	}
+
+	o.Severities = maps.Clone(profile.Severities)
+
+	if o.Severities == nil {
+		o.Severities = map[string]Severity{}
+	}
+}
+
+// Enable activates rules. The ID "all" activates every rule.
+func (o *RuleSet) Enable(ids ...string) error {
+	return o.toggle(true, ids)
+}
+
+// Disable deactivates rules. The ID "all" deactivates every rule.
+func (o *RuleSet) Disable(ids ...string) error {
+	return o.toggle(false, ids)
+}
+
+// toggle implements Enable and Disable.
+func (o *RuleSet) toggle(enabled bool, ids []string) error {
+	if o.Enabled == nil {
+		o.Enabled = map[string]bool{}
+	}
+
+	for _, id := range ids {
+		if id == "all" {
+			for id2 := range Rules() {
+				o.Enabled[id2] = enabled
+			}
+
+			continue
+		}
+
+		if err := checkRule(id); err != nil {
+			return err
+		}
+
+		o.Enabled[id] = enabled
+	}
+
+	return nil
+}
+
+// SetSeverity overrides the severity of a rule.
+func (o *RuleSet) SetSeverity(id string, severity Severity) error {
+	if err := checkRule(id); err != nil {
+		return err
+	}
+
+	if o.Severities == nil {
+		o.Severities = map[string]Severity{}
+	}
+
+	o.Severities[id] = severity
+	return nil
+}
+
+// IsEnabled reports whether a rule is active.
+func (o RuleSet) IsEnabled(id string) bool {
+	return o.Enabled[id]
+}
+
+// Severity reports the effective severity of a rule.
+func (o RuleSet) Severity(id string) Severity {
+	if severity, ok := o.Severities[id]; ok {
+		return severity
+	}
+
+	return Rules()[id].Severity
+}
+
+// Apply drops diagnostics for inactive rules, and applies severity overrides.
+func (o RuleSet) Apply(diagnostics []Diagnostic) []Diagnostic {
+	var result []Diagnostic
+
+	for _, diagnostic := range diagnostics {
+		if !o.IsEnabled(diagnostic.Rule) {
+			continue
+		}
+
+		if severity, ok := o.Severities[diagnostic.Rule]; ok {
+			diagnostic.Severity = severity
+		}
+
+		result = append(result, diagnostic)
+	}
+
+	return result
+}
+
+// Configure applies a profile, then any enable, disable, and severity customizations, in that order.
+// The wildcard "all" applies ahead of specific rule IDs, so that "disable all, enable eol" selects eol alone.
+//
+// A blank profile leaves the current selection in place.
+func (o *RuleSet) Configure(config FunkConfig) error {
+	if config.Profile != "" {
+		if err := o.ApplyProfile(config.Profile); err != nil {
+			return err
+		}
+	}
+
+	isAll := func(id string) bool { return id == "all" }
+	isSpecific := func(id string) bool { return id != "all" }
+
+	for _, step := range []struct {
+		toggle func(...string) error
+		ids    []string
+	}{
+		{o.Enable, slices.DeleteFunc(slices.Clone(config.Enable), isSpecific)},
+		{o.Disable, slices.DeleteFunc(slices.Clone(config.Disable), isSpecific)},
+		{o.Enable, slices.DeleteFunc(slices.Clone(config.Enable), isAll)},
+		{o.Disable, slices.DeleteFunc(slices.Clone(config.Disable), isAll)},
+	} {
+		if err := step.toggle(step.ids...); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(config.Severity)) {
+		if err := o.SetSeverity(id, config.Severity[id]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseRuleList splits a comma separated list of rule IDs, ignoring blanks.
+func ParseRuleList(s string) []string {
+	var ids []string
+
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// ParseSeverities reads a comma separated list of rule=severity overrides.
+func ParseSeverities(s string) (map[string]Severity, error) {
+	severities := map[string]Severity{}
+
+	for _, pair := range ParseRuleList(s) {
+		id, name, ok := strings.Cut(pair, "=")
+
+		if !ok {
+			return nil, fmt.Errorf("expected rule=severity, got %v", pair)
+		}
+
+		severity, err := ParseSeverity(strings.TrimSpace(name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		severities[strings.TrimSpace(id)] = severity
+	}
+
+	return severities, nil
+}
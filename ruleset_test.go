@@ -0,0 +1,121 @@
+package stank_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestNewRuleSetOmitsModulino(t *testing.T) {
+	rules := stank.NewRuleSet()
+
+	for _, id := range []string{stank.RuleModulino, stank.RuleBashisms, stank.RuleInterpreterMismatch, stank.RuleAltSyntax} {
+		if rules.IsEnabled(id) {
+			t.Errorf("expected %v disabled by default", id)
+		}
+	}
+
+	if !rules.IsEnabled(stank.RuleIFSReset) || !rules.IsEnabled(stank.RuleSyntax) {
+		t.Errorf("expected ifs-reset and syntax enabled by default")
+	}
+}
+
+func TestRuleSetConfigure(t *testing.T) {
+	rules := stank.NewRuleSet()
+
+	config := stank.FunkConfig{
+		Profile:  "portable",
+		Enable:   []string{stank.RuleSafetyFlags},
+		Disable:  []string{stank.RuleCR},
+		Severity: map[string]stank.Severity{stank.RuleEOL: stank.SeverityError},
+	}
+
+	if err := rules.Configure(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if !rules.IsEnabled(stank.RuleSafetyFlags) || rules.IsEnabled(stank.RuleCR) || rules.IsEnabled(stank.RuleTrapHazards) {
+		t.Errorf("expected portable selection plus safety-flags minus cr, got %v", rules.Enabled)
+	}
+
+	if rules.Severity(stank.RuleEOL) != stank.SeverityError {
+		t.Errorf("expected eol severity error, got %v", rules.Severity(stank.RuleEOL))
+	}
+
+	diagnostics := rules.Apply([]stank.Diagnostic{
+		stank.NewDiagnostic(stank.RuleEOL, "a.sh", "Missing final end of line sequence"),
+		stank.NewDiagnostic(stank.RuleCR, "a.sh", "CR/CRLF line ending detected"),
+	})
+
+	if len(diagnostics) != 1 || diagnostics[0].Rule != stank.RuleEOL || diagnostics[0].Severity != stank.SeverityError {
+		t.Errorf("expected a single eol error, got %v", diagnostics)
+	}
+}
+
+func TestRuleSetConfigureAppliesWildcardFirst(t *testing.T) {
+	rules := stank.NewRuleSet()
+
+	if err := rules.Configure(stank.FunkConfig{Enable: []string{stank.RuleEOL}, Disable: []string{"all"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for id := range stank.Rules() {
+		if rules.IsEnabled(id) != (id == stank.RuleEOL) {
+			t.Errorf("expected eol alone, got %v", rules.Enabled)
+		}
+	}
+}
+
+func TestRuleSetRejectsUnknownNames(t *testing.T) {
+	rules := stank.NewRuleSet()
+
+	if err := rules.Configure(stank.FunkConfig{Profile: "lax"}); err == nil {
+		t.Errorf("expected unknown profile error")
+	}
+
+	if err := rules.Configure(stank.FunkConfig{Enable: []string{"tabs"}}); err == nil {
+		t.Errorf("expected unknown rule error")
+	}
+
+	if _, err := stank.ParseSeverities("eol"); err == nil {
+		t.Errorf("expected malformed severity error")
+	}
+}
+
+func TestLoadConfigFunkTable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), stank.ConfigFilename)
+
+	configTOML := `[funk]
+profile = "security"
+disable = ["trap-hazards"]
+
+[funk.severity]
+shebang = "info"
+`
+
+	if err := os.WriteFile(configPath, []byte(configTOML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := stank.LoadConfig(configPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := stank.NewRuleSet()
+
+	if err := rules.Configure(config.Funk); err != nil {
+		t.Fatal(err)
+	}
+
+	if rules.IsEnabled(stank.RuleTrapHazards) || !rules.IsEnabled(stank.RuleIFSReset) || rules.IsEnabled(stank.RuleEOL) {
+		t.Errorf("expected security selection minus trap-hazards, got %v", rules.Enabled)
+	}
+
+	if rules.Severity(stank.RuleShebang) != stank.SeverityInfo || rules.Severity(stank.RuleIFSReset) != stank.SeverityError {
+		t.Errorf("expected shebang info and ifs-reset error, got %v", rules.Severities)
+	}
+}
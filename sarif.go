@@ -0,0 +1,208 @@
+package stank
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+)
+
+// SARIFSchema identifies the SARIF 2.1.0 JSON schema.
+const SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIFVersion denotes the SARIF format version.
+const SARIFVersion = "2.1.0"
+
+// SARIFLog models a SARIF log file.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun models a single analysis run.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool models the analysis tool.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver models the analysis tool's primary component.
+type SARIFDriver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []SARIFRuleDescriptor `json:"rules"`
+}
+
+// SARIFRuleDescriptor models rule metadata.
+type SARIFRuleDescriptor struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     SARIFMessage           `json:"shortDescription"`
+	DefaultConfiguration SARIFRuleConfiguration `json:"defaultConfiguration"`
+}
+
+// SARIFRuleConfiguration models default rule settings.
+type SARIFRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFMessage models plain text.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult models a finding.
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFLocation models a finding location.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation models a file region.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation models a file reference.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion models a position within a file.
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIFLevel converts a severity to a SARIF result level.
+func SARIFLevel(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// SARIFURI converts a file path to a SARIF artifact URI.
+//
+// Relative paths remain relative, resolved against the scan's working directory.
+func SARIFURI(pth string) string {
+	u := url.URL{Path: filepath.ToSlash(pth)}
+
+	if filepath.IsAbs(pth) {
+		u.Scheme = "file"
+	}
+
+	return u.String()
+}
+
+// SARIFReporter renders diagnostics as a SARIF 2.1.0 log, with one run.
+//
+// Diagnostics are buffered until Close.
+type SARIFReporter struct {
+	// Out receives the report.
+	Out io.Writer
+
+	// Rules describes the checks, in rule descriptor order.
+	Rules []Rule
+
+	// Results collects the findings.
+	Results []SARIFResult
+}
+
+// NewSARIFReporter constructs a SARIFReporter describing every rule in Rules.
+func NewSARIFReporter(out io.Writer) *SARIFReporter {
+	rules := Rules()
+	var descriptors []Rule
+
+	for _, id := range slices.Sorted(maps.Keys(rules)) {
+		descriptors = append(descriptors, rules[id])
+	}
+
+	return &SARIFReporter{Out: out, Rules: descriptors}
+}
+
+// Report buffers a diagnostic.
+func (o *SARIFReporter) Report(diagnostic Diagnostic) error {
+	ruleIndex := slices.IndexFunc(o.Rules, func(rule Rule) bool { return rule.ID == diagnostic.Rule })
+
+	if ruleIndex == -1 {
+		o.Rules = append(o.Rules, Rule{ID: diagnostic.Rule, Severity: diagnostic.Severity})
+		ruleIndex = len(o.Rules) - 1
+	}
+
+	location := SARIFLocation{
+		PhysicalLocation: SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: SARIFURI(diagnostic.Path)},
+		},
+	}
+
+	if diagnostic.Line > 0 {
+		location.PhysicalLocation.Region = &SARIFRegion{
+			StartLine:   diagnostic.Line,
+			StartColumn: diagnostic.Column,
+		}
+	}
+
+	o.Results = append(o.Results, SARIFResult{
+		RuleID:    diagnostic.Rule,
+		RuleIndex: ruleIndex,
+		Level:     SARIFLevel(diagnostic.Severity),
+		Message:   SARIFMessage{Text: diagnostic.Message},
+		Locations: []SARIFLocation{location},
+	})
+
+	return nil
+}
+
+// Close renders the SARIF log.
+func (o *SARIFReporter) Close() error {
+	driver := SARIFDriver{
+		Name:           "funk",
+		Version:        Version,
+		InformationURI: "https://github.com/mcandre/stank",
+		Rules:          []SARIFRuleDescriptor{},
+	}
+
+	for _, rule := range o.Rules {
+		driver.Rules = append(driver.Rules, SARIFRuleDescriptor{
+			ID:                   rule.ID,
+			ShortDescription:     SARIFMessage{Text: rule.Description},
+			DefaultConfiguration: SARIFRuleConfiguration{Level: SARIFLevel(rule.Severity)},
+		})
+	}
+
+	results := o.Results
+
+	if results == nil {
+		results = []SARIFResult{}
+	}
+
+	log := SARIFLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs:    []SARIFRun{{Tool: SARIFTool{Driver: driver}, Results: results}},
+	}
+
+	encoder := json.NewEncoder(o.Out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
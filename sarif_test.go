@@ -0,0 +1,54 @@
+package stank_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestSARIFReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := stank.NewSARIFReporter(&buf)
+
+	diagnostic := stank.NewDiagnostic(stank.RuleSyntax, "examples/hello world.sh", "sh syntax error: reached EOF")
+	diagnostic.Line = 2
+	diagnostic.Column = 6
+
+	if err := reporter.Report(diagnostic); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var log stank.SARIFLog
+
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected one SARIF 2.1.0 run, got %v", log)
+	}
+
+	run := log.Runs[0]
+
+	if len(run.Tool.Driver.Rules) != len(stank.Rules()) {
+		t.Errorf("expected a descriptor for each rule, got %v", run.Tool.Driver.Rules)
+	}
+
+	result := run.Results[0]
+
+	if run.Tool.Driver.Rules[result.RuleIndex].ID != stank.RuleSyntax || result.Level != "error" {
+		t.Errorf("expected syntax error result, got %v", result)
+	}
+
+	location := result.Locations[0].PhysicalLocation
+
+	if location.ArtifactLocation.URI != "examples/hello%20world.sh" || location.Region == nil || location.Region.StartLine != 2 || location.Region.StartColumn != 6 {
+		t.Errorf("expected located result, got %v", location)
+	}
+}
@@ -142,6 +142,10 @@ type Smell struct {
 	// Ksh denotes whether the file path appears to be a ksh family script.
 	Ksh bool `json:"ksh"`
 
+	// Dialect denotes the least powerful shell language which parses a POSIXy script,
+	// such as posix, mksh, bash, or zsh.
+	Dialect string `json:"dialect"`
+
 	// AltShellScript denotes whether the file path appears to be a non-POSIX family shell script.
 	AltShellScript bool `json:"alt_shell_script"`
 
@@ -204,6 +208,7 @@ func (o *Smell) UnmarshalJSON(bs []byte) error {
 	o.POSIXy = aux.POSIXy
 	o.Bash = aux.Bash
 	o.Ksh = aux.Ksh
+	o.Dialect = aux.Dialect
 	o.AltShellScript = aux.AltShellScript
 	o.CoreConfiguration = aux.CoreConfiguration
 	o.MachineGenerated = aux.MachineGenerated
@@ -5,9 +5,11 @@ package stank
 import (
 	"bufio"
 	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -27,12 +29,15 @@ var Ignores = sync.OnceValue(func() []string {
 	}
 })
 
-// Ignore is a poor man's gitignore.
+// Ignore reports whether a path features any of the common exclusions in Ignores().
 //
-// TODO: https://github.com/mcandre/stank/issues/1
+// For full gitignore semantics, see Walk.
 func Ignore(pth string) bool {
-	ignores := Ignores()
+	return IgnoreAny(pth, Ignores())
+}
 
+// IgnoreAny reports whether a path features any of the given path components.
+func IgnoreAny(pth string, ignores []string) bool {
 	for _, part := range strings.Split(pth, string(os.PathSeparator)) {
 		for _, ignore := range ignores {
 			if part == ignore {
@@ -280,6 +285,7 @@ var LowerExtensionsToInterpreter = sync.OnceValue(func() map[string]string {
 		".pdkshrc":      "pdksh",
 		".php":          "php",
 		".pmakefile":    "pmake",
+		".ps1":          "pwsh",
 		".poshrc":       "posh",
 		".profile":      "sh",
 		".rc":           "rc",
@@ -372,6 +378,12 @@ var Boms = sync.OnceValue(func() [][]byte {
 	}
 })
 
+// IsEnvSplitString reports whether an env argument requests splitting the remainder of a shebang into words,
+// as in #!/usr/bin/env -S bash -e.
+func IsEnvSplitString(arg string) bool {
+	return arg == "-S" || arg == "--split-string"
+}
+
 // IsBOM checks whether a byte sequence is a BOM.
 func (o Sniffer) IsBOM(bs []byte) bool {
 	boms := o.Boms
@@ -459,6 +471,17 @@ var KshInterpreters = sync.OnceValue(func() map[string]bool {
 	}
 })
 
+// MirBSDKornInterpreters note when a ksh is a member of the pdksh lineage,
+// which the mvdan.cc/sh MirBSD Korn variant parses faithfully.
+var MirBSDKornInterpreters = sync.OnceValue(func() map[string]bool {
+	return map[string]bool{
+		"lksh":  true,
+		"mksh":  true,
+		"oksh":  true,
+		"pdksh": true,
+	}
+})
+
 // SniffConfig bundles together the various options when sniffing files for POSIXyNESS.
 type SniffConfig struct {
 	// EOLCheck analyzes End Of Line termination.
@@ -466,6 +489,9 @@ type SniffConfig struct {
 
 	// CRCheck analyzes line terminations.
 	CRCheck bool
+
+	// DialectCheck infers the minimal shell dialect of POSIXy scripts, given the full contents.
+	DialectCheck bool
 }
 
 // AltInterpreters provides some alternative shell interpreters.
@@ -477,6 +503,7 @@ var AltInterpreters = sync.OnceValue(func() map[string]bool {
 		"fish":   true,
 		"ion":    true,
 		"lksh":   true,
+		"pwsh":   true,
 		"rc":     true,
 		"tcsh":   true,
 		"tsh":    true,
@@ -495,6 +522,7 @@ var AltExtensions = sync.OnceValue(func() map[string]bool {
 		".ion":    true,
 		".ionrc":  true,
 		".lksh":   true,
+		".ps1":    true,
 		".rc":     true,
 		".rcrc":   true,
 		".tcsh":   true,
@@ -519,52 +547,70 @@ func (o Sniffer) IsAltShellScript(smell Smell) bool {
 
 // POSIXShCheckSyntax validates syntax for strict POSIX sh compliance.
 func POSIXShCheckSyntax(smell Smell) error {
-	parser := syntax.NewParser(syntax.Variant(syntax.LangPOSIX))
-
 	fd, err := os.Open(smell.Path)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = parser.Parse(bufio.NewReader(fd), smell.Path)
-	return err
+	defer func() {
+		if err2 := fd.Close(); err2 != nil {
+			log.Panic(err2)
+		}
+	}()
+
+	parser := syntax.NewParser(syntax.Variant(syntax.LangPOSIX))
+
+	if _, err := parser.Parse(bufio.NewReader(fd), smell.Path); err != nil {
+		return BackendError{Backend: fmt.Sprintf("%v %v", ParserBackend, syntax.LangPOSIX), Err: err}
+	}
+
+	return nil
 }
 
 // UnixCheckSyntax validates syntax for the wider UNIX shell family.
 func UnixCheckSyntax(smell Smell) error {
-	cmd := exec.Command(smell.Interpreter, "-n", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, smell.Interpreter, "-n")
+}
+
+// ElvishCheckSyntax validates syntax for elvish.
+func ElvishCheckSyntax(smell Smell) error {
+	return runSyntaxValidator(smell, smell.Interpreter, "-compileonly")
+}
+
+// PowerShellCheckSyntax validates syntax for PowerShell, by way of the PowerShell language parser.
+func PowerShellCheckSyntax(smell Smell) error {
+	script := fmt.Sprintf(`$errors = $null
+$null = [System.Management.Automation.Language.Parser]::ParseFile('%v', [ref]$null, [ref]$errors)
+foreach ($e in $errors) { '{0}:{1}:{2}: {3}' -f $e.Extent.File, $e.Extent.StartLineNumber, $e.Extent.StartColumnNumber, $e.Message }
+if ($errors) { exit 1 }`, strings.ReplaceAll(smell.Path, "'", "''"))
+
+	return runSyntaxCommand(smell, smell.Interpreter+" Parser.ParseFile", smell.Interpreter, "-NoProfile", "-NonInteractive", "-Command", script)
 }
 
 // PerlishCheckSyntax validates syntax for Perl, Ruby, and Node.js.
 func PerlishCheckSyntax(smell Smell) error {
-	cmd := exec.Command(smell.Interpreter, "-c", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, smell.Interpreter, "-c")
 }
 
 // PHPCheckSyntax validates syntax for PHP.
 func PHPCheckSyntax(smell Smell) error {
-	cmd := exec.Command(smell.Interpreter, "-l", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, smell.Interpreter, "-l")
 }
 
 // PythonCheckSyntax validates syntax for Python.
 func PythonCheckSyntax(smell Smell) error {
-	cmd := exec.Command(smell.Interpreter, "-m", "py_compile", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, smell.Interpreter, "-m", "py_compile")
 }
 
 // GoCheckSyntax validates syntax for Go.
 func GoCheckSyntax(smell Smell) error {
-	cmd := exec.Command("gofmt", "-e", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, "gofmt", "-e")
 }
 
 // GNUAwkCheckSyntax validates syntax for GNU awk files.
 func GNUAwkCheckSyntax(smell Smell) error {
-	cmd := exec.Command(smell.Interpreter, "--lint", "-f", smell.Path)
-	return cmd.Run()
+	return runSyntaxValidator(smell, smell.Interpreter, "--lint", "-f")
 }
 
 // Interpreter2SyntaxValidator provides syntax validator delegates, if one is available.
@@ -577,11 +623,12 @@ var Interpreter2SyntaxValidator = sync.OnceValue(func() map[string]func(Smell) e
 		"bosh":       UnixCheckSyntax,
 		"csh":        UnixCheckSyntax,
 		"dash":       UnixCheckSyntax,
-		"elvish":     UnixCheckSyntax,
+		"elvish":     ElvishCheckSyntax,
 		"fish":       UnixCheckSyntax,
 		"gawk":       GNUAwkCheckSyntax,
 		"generic-sh": POSIXShCheckSyntax,
 		"gmake":      UnixCheckSyntax,
+		"ion":        UnixCheckSyntax,
 		"go":         GoCheckSyntax,
 		"iojs":       PerlishCheckSyntax,
 		"ksh":        UnixCheckSyntax,
@@ -600,6 +647,7 @@ var Interpreter2SyntaxValidator = sync.OnceValue(func() map[string]func(Smell) e
 		"php":        PHPCheckSyntax,
 		"pmake":      UnixCheckSyntax,
 		"posh":       UnixCheckSyntax,
+		"pwsh":       PowerShellCheckSyntax,
 		"python":     PythonCheckSyntax,
 		"python3":    PythonCheckSyntax,
 		"rc":         UnixCheckSyntax,
@@ -699,17 +747,68 @@ func NewSniffer() Sniffer {
 //
 // If an I/O problem occurs during analysis, an error value will be set.
 // Otherwise, the error value will be nil.
+//
+// Sniff is a thin wrapper around SniffFS, by way of os.DirFS.
 func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
-	// Attempt to short-circuit for directories
-	fi, err := os.Lstat(pth)
+	fsys, name := osFS(pth)
+	smell, err := o.SniffFS(fsys, name, config)
+	smell.Path = pth
+	return smell, err
+}
 
-	smell := Smell{Path: pth}
+// SniffFS analyzes the smell of a named file within a file system,
+// such as embed.FS, zip.Reader, or fstest.MapFS.
+//
+// Symlinks are not followed, when fsys implements fs.ReadLinkFS.
+//
+// SniffFS is a thin wrapper around SniffReader.
+func (o Sniffer) SniffFS(fsys fs.FS, name string, config SniffConfig) (Smell, error) {
+	return o.sniffFS(fsys, name, config, false)
+}
 
-	if err != nil {
+// sniffFS implements SniffFS, optionally following symlinks.
+func (o Sniffer) sniffFS(fsys fs.FS, name string, config SniffConfig, followSymlinks bool) (Smell, error) {
+	analysis, err := o.analyzeFS(fsys, name, config, followSymlinks, false)
+	return analysis.Smell, err
+}
+
+// SniffBytes analyzes the smell of in-memory file contents.
+//
+// See SniffReader.
+func (o Sniffer) SniffBytes(pth string, mode fs.FileMode, contents []byte, config SniffConfig) (Smell, error) {
+	return o.SniffReader(pth, mode, bytes.NewReader(contents), int64(len(contents)), config)
+}
+
+// SniffReader analyzes the smell of file contents of the given size,
+// such as editor buffers, git blobs, or uploads.
+//
+// The path need not exist, but informs filename based heuristics.
+// The mode supplies permission, directory, and symlink metadata.
+// Directories and symlinks are not read.
+//
+// Given DialectCheck, POSIXy contents are read in full, in order to infer the dialect.
+//
+// See Sniff.
+func (o Sniffer) SniffReader(pth string, mode fs.FileMode, r io.ReaderAt, size int64, config SniffConfig) (Smell, error) {
+	smell, err := o.sniffReader(pth, mode, r, size, config)
+
+	if !config.DialectCheck || !smell.POSIXy || (err != nil && err != io.EOF) {
 		return smell, err
 	}
 
-	mode := fi.Mode()
+	contents := make([]byte, size)
+
+	if _, err2 := io.ReadFull(io.NewSectionReader(r, 0, size), contents); err2 != nil {
+		return smell, err2
+	}
+
+	smell.Dialect = o.NewAnalysis(smell, contents).Dialect()
+	return smell, err
+}
+
+// sniffReader implements SniffReader, leaving dialect inference to callers holding the full contents.
+func (o Sniffer) sniffReader(pth string, mode fs.FileMode, r io.ReaderAt, size int64, config SniffConfig) (Smell, error) {
+	smell := Smell{Path: pth}
 
 	if mode.IsDir() {
 		smell.Directory = true
@@ -744,11 +843,11 @@ func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
 	}
 
 	smell.CoreConfiguration = o.LowerExtensionsToConfig[strings.ToLower(smell.Extension)] ||
-		LowerFilenamesToConfig()[strings.ToLower(smell.Filename)]
+		o.LowerFilenamesToConfig[strings.ToLower(smell.Filename)]
 
 	smell.Library = (smell.CoreConfiguration || smell.Extension != "") && !smell.OwnerExecutable
 
-	smell.Symlink = fi.Mode()&os.ModeSymlink != 0
+	smell.Symlink = mode&os.ModeSymlink != 0
 
 	if smell.Symlink {
 		return smell, nil
@@ -760,30 +859,16 @@ func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
 		smell.Interpreter = extensionInterpreter
 	}
 
-	fd, err := os.Open(pth)
-
-	if err != nil {
-		return smell, err
-	}
-
-	defer func() {
-		err = fd.Close()
-
-		if err != nil {
-			log.Panic(err)
-		}
-	}()
-
 	//
 	// Check for BOMs
 	//
 
-	br := bufio.NewReader(fd)
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
 
 	maxBOMCheckLength := 5
 
-	if fi.Size() < 5 {
-		maxBOMCheckLength = int(fi.Size())
+	if size < 5 {
+		maxBOMCheckLength = int(size)
 	}
 
 	bs, err := br.Peek(maxBOMCheckLength)
@@ -839,31 +924,16 @@ func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
 	//
 	// Read the entire script in order to assess the presence/absence of a final POSIX end of line (\n) sequence.
 	//
-	if config.EOLCheck && fi.Size() > 0 {
-		fd2, err := os.Open(pth)
-
-		if err != nil {
-			log.Print(err)
-			return smell, nil
-		}
-
-		defer func() {
-			err := fd2.Close()
-
-			if err != nil {
-				log.Panic(err)
-			}
-		}()
-
+	if config.EOLCheck && size > 0 {
 		maxEOLSequenceLength := int64(2)
 
-		if fi.Size() < 2 {
+		if size < 2 {
 			maxEOLSequenceLength = 1
 		}
 
 		eolBuf := make([]byte, maxEOLSequenceLength)
 
-		if _, err := fd2.ReadAt(eolBuf, fi.Size()-maxEOLSequenceLength); err != nil {
+		if _, err := r.ReadAt(eolBuf, size-maxEOLSequenceLength); err != nil {
 			return smell, err
 		}
 
@@ -909,9 +979,13 @@ func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
 
 	commandParts := strings.Split(command, " ")
 
-	// Strip /usr/bin/env, if present
+	// Strip /usr/bin/env, if present, along with any -S option splitting the interpreter from its flags
 	if commandParts[0] == "/usr/bin/env" {
 		commandParts = commandParts[1:]
+
+		if len(commandParts) > 1 && IsEnvSplitString(commandParts[0]) {
+			commandParts = commandParts[1:]
+		}
 	}
 
 	// Strip /bin/busybox, if present
@@ -951,21 +1025,7 @@ func (o Sniffer) Sniff(pth string, config SniffConfig) (Smell, error) {
 	}
 
 	if (smell.POSIXy || smell.AltShellScript) && config.CRCheck {
-		fd3, err := os.Open(pth)
-
-		defer func() {
-			err = fd3.Close()
-
-			if err != nil {
-				log.Panic(err)
-			}
-		}()
-
-		if err != nil {
-			return smell, err
-		}
-
-		br2 := bufio.NewReader(fd3)
+		br2 := bufio.NewReader(io.NewSectionReader(r, 0, size))
 
 		CR := byte('\r')
 
@@ -1,6 +1,10 @@
 package stank_test
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/mcandre/stank"
@@ -11,3 +15,49 @@ func TestVersion(t *testing.T) {
 		t.Errorf("Expected stank version to be non-blank")
 	}
 }
+
+func TestSniffBytesMatchesSniff(t *testing.T) {
+	sniffer := stank.NewSniffer()
+	config := stank.SniffConfig{EOLCheck: true, CRCheck: true}
+
+	entries, err := os.ReadDir("examples")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		pth := filepath.Join("examples", entry.Name())
+		smell, err := sniffer.Sniff(pth, config)
+
+		if err != nil && err != io.EOF {
+			t.Error(err)
+		}
+
+		fi, err := os.Lstat(pth)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		contents, err := os.ReadFile(pth)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		smell2, err := sniffer.SniffBytes(pth, fi.Mode(), contents, config)
+
+		if err != nil && err != io.EOF {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(smell2, smell) {
+			t.Errorf("expected in-memory smell %v to equal %v", smell2, smell)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package stank
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SuppressionPattern matches funk suppression directives, like `# funk:disable=ifs-reset,safety-flags`.
+var SuppressionPattern = regexp.MustCompile(`^\s*#\s*funk:disable=([^\s#]+)`)
+
+// Suppression models a single rule named by a funk:disable directive.
+//
+// Directives in the leading comment block of a script apply to the whole file.
+// Directives elsewhere apply to the next line which is neither blank nor a comment,
+// in the manner of shellcheck directives.
+type Suppression struct {
+	// Rule identifies the suppressed check, or "all".
+	Rule string
+
+	// Line is the 1-based line number of the directive.
+	Line int
+
+	// Target is the 1-based line number of the suppressed line,
+	// zero for the whole file, or -1 when no line follows the directive.
+	Target int
+}
+
+// Matches reports whether the suppression covers a diagnostic.
+func (o Suppression) Matches(diagnostic Diagnostic) bool {
+	if o.Rule != "all" && o.Rule != diagnostic.Rule {
+		return false
+	}
+
+	return o.Target == 0 || o.Target == diagnostic.Line
+}
+
+// isCode reports whether a line is neither blank nor a comment.
+func isCode(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && !strings.HasPrefix(line, "#")
+}
+
+// ParseSuppressions collects the funk:disable directives from script lines.
+func ParseSuppressions(lines []string) []Suppression {
+	var suppressions []Suppression
+	header := true
+
+	for i, line := range lines {
+		if isCode(line) {
+			header = false
+			continue
+		}
+
+		m := SuppressionPattern.FindStringSubmatch(line)
+
+		if m == nil {
+			continue
+		}
+
+		target := 0
+
+		if !header {
+			target = -1
+
+			for j := i + 1; j < len(lines); j++ {
+				if isCode(lines[j]) {
+					target = j + 1
+					break
+				}
+			}
+		}
+
+		for _, rule := range ParseRuleList(m[1]) {
+			suppressions = append(suppressions, Suppression{Rule: rule, Line: i + 1, Target: target})
+		}
+	}
+
+	return suppressions
+}
+
+// Suppress drops diagnostics covered by any suppression,
+// marking the covering suppressions in used, which parallels suppressions.
+func Suppress(diagnostics []Diagnostic, suppressions []Suppression, used []bool) []Diagnostic {
+	var result []Diagnostic
+
+	for _, diagnostic := range diagnostics {
+		suppressed := false
+
+		for i, suppression := range suppressions {
+			if suppression.Matches(diagnostic) {
+				used[i] = true
+				suppressed = true
+			}
+		}
+
+		if !suppressed {
+			result = append(result, diagnostic)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package stank_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcandre/stank"
+)
+
+func TestParseSuppressions(t *testing.T) {
+	lines := []string{
+		"#!/bin/sh",
+		"# funk:disable=safety-flags,ifs-reset",
+		"echo hi",
+		"  # funk:disable=trap-hazards -- intentional handoff",
+		"",
+		"exec true",
+		"# funk:disable=all",
+	}
+
+	expected := []stank.Suppression{
+		{Rule: stank.RuleSafetyFlags, Line: 2, Target: 0},
+		{Rule: stank.RuleIFSReset, Line: 2, Target: 0},
+		{Rule: stank.RuleTrapHazards, Line: 4, Target: 6},
+		{Rule: "all", Line: 7, Target: -1},
+	}
+
+	if suppressions := stank.ParseSuppressions(lines); !reflect.DeepEqual(suppressions, expected) {
+		t.Errorf("expected %v, got %v", expected, suppressions)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	suppressions := []stank.Suppression{
+		{Rule: stank.RuleSafetyFlags, Line: 2, Target: 0},
+		{Rule: stank.RuleTrapHazards, Line: 4, Target: 6},
+		{Rule: stank.RuleEOL, Line: 8, Target: 9},
+	}
+
+	safety := stank.NewDiagnostic(stank.RuleSafetyFlags, "run", "Control program flow like `set -euf` at the top of executable scripts")
+	safety.Line = 3
+	exec := stank.NewDiagnostic(stank.RuleTrapHazards, "run", "exec discards traps")
+	exec.Line = 6
+	trap := stank.NewDiagnostic(stank.RuleTrapHazards, "run", "Traps may reset in subshells")
+	trap.Line = 5
+
+	used := make([]bool, len(suppressions))
+	diagnostics := stank.Suppress([]stank.Diagnostic{safety, exec, trap}, suppressions, used)
+
+	if len(diagnostics) != 1 || diagnostics[0] != trap {
+		t.Errorf("expected only the line 5 trap diagnostic, got %v", diagnostics)
+	}
+
+	if expected := []bool{true, true, false}; !reflect.DeepEqual(used, expected) {
+		t.Errorf("expected used %v, got %v", expected, used)
+	}
+}
@@ -0,0 +1,352 @@
+package stank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ParserBackend names the in-process syntax validation backend.
+const ParserBackend = "mvdan.cc/sh"
+
+// SyntaxValidatorTimeout bounds each external syntax validator run,
+// so that a broken interpreter cannot hang the caller.
+var SyntaxValidatorTimeout = 30 * time.Second
+
+// InterpreterError locates a syntax error reported by an external interpreter.
+type InterpreterError struct {
+	// Path locates the file.
+	Path string
+
+	// Line is the 1-based line number, or zero when unknown.
+	Line int
+
+	// Column is the 1-based column number, or zero when unknown.
+	Column int
+
+	// Message describes the error.
+	Message string
+}
+
+// Error renders the error in the manner of compiler diagnostics.
+func (o InterpreterError) Error() string {
+	switch {
+	case o.Line == 0:
+		return fmt.Sprintf("%v: %v", o.Path, o.Message)
+	case o.Column == 0:
+		return fmt.Sprintf("%v:%v: %v", o.Path, o.Line, o.Message)
+	default:
+		return fmt.Sprintf("%v:%v:%v: %v", o.Path, o.Line, o.Column, o.Message)
+	}
+}
+
+// BackendError attributes a syntax error to the validation backend which reported it,
+// such as bash -n, or mvdan.cc/sh bash.
+type BackendError struct {
+	// Backend names the validator.
+	Backend string
+
+	// Err is the underlying syntax error.
+	Err error
+}
+
+// Error renders the error, prefixed by the backend.
+func (o BackendError) Error() string {
+	return fmt.Sprintf("%v: %v", o.Backend, o.Err)
+}
+
+// Unwrap yields the underlying syntax error.
+func (o BackendError) Unwrap() error {
+	return o.Err
+}
+
+// InterpreterOutputParser extracts the first located error from interpreter output.
+type InterpreterOutputParser func(output string) (InterpreterError, bool)
+
+var (
+	// bashOutputPattern matches bash errors, like bad.sh: line 3: syntax error: unexpected end of file.
+	bashOutputPattern = regexp.MustCompile(`(?m)line (\d+): (.+)$`)
+
+	// dashOutputPattern matches dash errors, like bad.sh: 3: Syntax error: end of file unexpected.
+	dashOutputPattern = regexp.MustCompile(`(?m)^.*?: (\d+): (.+)$`)
+
+	// colonOutputPattern matches errors like bad.zsh:3: parse error near `fi', optionally with a column.
+	colonOutputPattern = regexp.MustCompile(`(?m)^.*?:(\d+):(?:(\d+):)? (.+)$`)
+
+	// kshOutputPattern matches ksh93 errors, like ksh: bad.ksh: syntax error at line 3: `fi' unexpected.
+	kshOutputPattern = regexp.MustCompile(`(?m)syntax error at line (\d+): (.+)$`)
+
+	// mkshOutputPattern matches mksh and pdksh errors, like mksh: bad.ksh[3]: syntax error: 'fi' unexpected.
+	mkshOutputPattern = regexp.MustCompile(`(?m)\[(\d+)\]: (.+)$`)
+
+	// perlOutputPattern matches perl errors, like syntax error at bad.pl line 1, near "if true".
+	perlOutputPattern = regexp.MustCompile(`(?m)^(.+?) at .+ line (\d+)(.*)$`)
+
+	// phpOutputPattern matches php errors, like PHP Parse error:  syntax error, unexpected end of file in bad.php on line 3.
+	phpOutputPattern = regexp.MustCompile(`(?m)error:\s+(.+) in .+ on line (\d+)`)
+
+	// nodeOutputPattern matches node error locations, like /tmp/bad.js:1.
+	nodeOutputPattern = regexp.MustCompile(`(?m)^.+:(\d+)$`)
+
+	// pythonOutputPattern matches python error locations, like File "bad.py", line 1.
+	pythonOutputPattern = regexp.MustCompile(`(?m)File ".*", line (\d+)`)
+
+	// fishOutputPattern matches fish errors, like bad.fish (line 3): Missing end to balance this if statement.
+	fishOutputPattern = regexp.MustCompile(`(?m)\(line (\d+)\): (.+)$`)
+
+	// elvishMessagePattern matches elvish error summaries, like Parse error: should be '}'.
+	elvishMessagePattern = regexp.MustCompile(`(?m)^(\w+ error: .+)$`)
+
+	// elvishLocationPattern matches elvish error locations, like /tmp/bad.elv:1:7-8: ....
+	elvishLocationPattern = regexp.MustCompile(`(?m)^\s+.*?:(\d+):(\d+)`)
+
+	// exceptionOutputPattern matches exception summaries, like SyntaxError: '(' was never closed.
+	exceptionOutputPattern = regexp.MustCompile(`(?m)^(\w*Error(?: \[\w+\])?: .+)$`)
+)
+
+// atoi converts regular expression submatches, treating blanks as zero.
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// ParseBashOutput parses bash -n output.
+func ParseBashOutput(output string) (InterpreterError, bool) {
+	m := bashOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[1]), Message: m[2]}, true
+}
+
+// ParseDashOutput parses dash, ash, and posh -n output.
+func ParseDashOutput(output string) (InterpreterError, bool) {
+	m := dashOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[1]), Message: m[2]}, true
+}
+
+// ParseColonOutput parses output in the common path:line: message or path:line:column: message format,
+// as from zsh, ruby, and gofmt.
+func ParseColonOutput(output string) (InterpreterError, bool) {
+	m := colonOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[1]), Column: atoi(m[2]), Message: m[3]}, true
+}
+
+// ParseKshOutput parses ksh family -n output.
+func ParseKshOutput(output string) (InterpreterError, bool) {
+	if m := kshOutputPattern.FindStringSubmatch(output); m != nil {
+		return InterpreterError{Line: atoi(m[1]), Message: "syntax error: " + m[2]}, true
+	}
+
+	if m := mkshOutputPattern.FindStringSubmatch(output); m != nil {
+		return InterpreterError{Line: atoi(m[1]), Message: m[2]}, true
+	}
+
+	return InterpreterError{}, false
+}
+
+// ParsePerlOutput parses perl -c output.
+func ParsePerlOutput(output string) (InterpreterError, bool) {
+	m := perlOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[2]), Message: m[1] + strings.TrimSuffix(m[3], ".")}, true
+}
+
+// ParsePHPOutput parses php -l output.
+func ParsePHPOutput(output string) (InterpreterError, bool) {
+	m := phpOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[2]), Message: m[1]}, true
+}
+
+// ParseNodeOutput parses node -c output,
+// locating the column by the caret beneath the quoted source line.
+func ParseNodeOutput(output string) (InterpreterError, bool) {
+	loc := nodeOutputPattern.FindStringSubmatchIndex(output)
+	m := exceptionOutputPattern.FindStringSubmatch(output)
+
+	if loc == nil || m == nil {
+		return InterpreterError{}, false
+	}
+
+	interpreterError := InterpreterError{Line: atoi(output[loc[2]:loc[3]]), Message: m[1]}
+	lines := strings.Split(output[loc[1]:], "\n")
+
+	if len(lines) > 2 {
+		if caret := strings.Index(lines[2], "^"); caret != -1 {
+			interpreterError.Column = caret + 1
+		}
+	}
+
+	return interpreterError, true
+}
+
+// ParsePythonOutput parses python -m py_compile output.
+//
+// Python dedents the quoted source line, so columns are omitted.
+func ParsePythonOutput(output string) (InterpreterError, bool) {
+	loc := pythonOutputPattern.FindStringSubmatch(output)
+	m := exceptionOutputPattern.FindStringSubmatch(output)
+
+	if loc == nil || m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(loc[1]), Message: m[1]}, true
+}
+
+// ParseFishOutput parses fish -n output.
+func ParseFishOutput(output string) (InterpreterError, bool) {
+	m := fishOutputPattern.FindStringSubmatch(output)
+
+	if m == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(m[1]), Message: m[2]}, true
+}
+
+// ParseElvishOutput parses elvish -compileonly output.
+func ParseElvishOutput(output string) (InterpreterError, bool) {
+	m := elvishMessagePattern.FindStringSubmatch(output)
+	loc := elvishLocationPattern.FindStringSubmatch(output)
+
+	if m == nil || loc == nil {
+		return InterpreterError{}, false
+	}
+
+	return InterpreterError{Line: atoi(loc[1]), Column: atoi(loc[2]), Message: m[1]}, true
+}
+
+// InterpreterOutputParsers provides interpreter output parsers, by interpreter.
+//
+// Other interpreters fall back to ParseColonOutput.
+var InterpreterOutputParsers = sync.OnceValue(func() map[string]InterpreterOutputParser {
+	return map[string]InterpreterOutputParser{
+		"ash":     ParseDashOutput,
+		"bash":    ParseBashOutput,
+		"bash4":   ParseBashOutput,
+		"busybox": ParseBashOutput,
+		"dash":    ParseDashOutput,
+		"elvish":  ParseElvishOutput,
+		"fish":    ParseFishOutput,
+		"iojs":    ParseNodeOutput,
+		"ksh":     ParseKshOutput,
+		"ksh88":   ParseKshOutput,
+		"ksh93":   ParseKshOutput,
+		"lksh":    ParseKshOutput,
+		"mksh":    ParseKshOutput,
+		"node":    ParseNodeOutput,
+		"oksh":    ParseKshOutput,
+		"pdksh":   ParseKshOutput,
+		"perl":    ParsePerlOutput,
+		"perl6":   ParsePerlOutput,
+		"php":     ParsePHPOutput,
+		"posh":    ParseDashOutput,
+		"python":  ParsePythonOutput,
+		"python3": ParsePythonOutput,
+		"rksh":    ParseKshOutput,
+		"ruby":    ParseColonOutput,
+		"zsh":     ParseColonOutput,
+	}
+})
+
+// ParseInterpreterOutput converts the output of a failed syntax validator into an InterpreterError.
+//
+// Unlocated output yields an InterpreterError with the first line of output as the message.
+// Blank output yields the original error.
+func ParseInterpreterOutput(smell Smell, output string, err error) error {
+	parser, ok := InterpreterOutputParsers()[smell.Interpreter]
+
+	if !ok {
+		parser = ParseColonOutput
+	}
+
+	if interpreterError, ok := parser(output); ok {
+		interpreterError.Path = smell.Path
+		interpreterError.Message = strings.TrimSpace(interpreterError.Message)
+		return interpreterError
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return InterpreterError{Path: smell.Path, Message: line}
+		}
+	}
+
+	return err
+}
+
+// runSyntaxValidator executes an external syntax validator on the script, by way of runSyntaxCommand.
+//
+// The script path follows the given flags.
+func runSyntaxValidator(smell Smell, name string, flags ...string) error {
+	backend := strings.Join(append([]string{name}, flags...), " ")
+	args := append(append([]string{}, flags...), smell.Path)
+	return runSyntaxCommand(smell, backend, name, args...)
+}
+
+// runSyntaxCommand executes a syntax validation command within SyntaxValidatorTimeout,
+// parsing any failure output, and attributing failures to the backend.
+func runSyntaxCommand(smell Smell, backend string, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), SyntaxValidatorTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	// Release stray descendants still holding the output pipe.
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return BackendError{Backend: backend, Err: InterpreterError{Path: smell.Path, Message: fmt.Sprintf("timed out after %v", SyntaxValidatorTimeout)}}
+	}
+
+	return BackendError{Backend: backend, Err: ParseInterpreterOutput(smell, string(output), err)}
+}
+
+// FallbackVariant selects an in-process parser variant for a shell interpreter, if any,
+// per the sniffer's interpreter tables.
+//
+// AT&T ksh88 and ksh93 have no faithful variant, so they never fall back.
+func (o Sniffer) FallbackVariant(interpreter string) (syntax.LangVariant, bool) {
+	switch {
+	case POSIXShInterpreters()[interpreter], o.FullBashInterpreters[interpreter], MirBSDKornInterpreters()[interpreter], interpreter == "zsh", interpreter == "bats":
+		return o.Variant(interpreter), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,180 @@
+package stank_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mcandre/stank"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestParseInterpreterOutput(t *testing.T) {
+	for _, tc := range []struct {
+		interpreter string
+		output      string
+		expected    stank.InterpreterError
+	}{
+		{
+			"bash",
+			"bad.sh: line 1: syntax error near unexpected token `newline'\nbad.sh: line 1: `echo ('\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Message: "syntax error near unexpected token `newline'"},
+		},
+		{
+			"dash",
+			"bad.sh: 3: Syntax error: end of file unexpected (expecting \"fi\")\n",
+			stank.InterpreterError{Path: "bad", Line: 3, Message: "Syntax error: end of file unexpected (expecting \"fi\")"},
+		},
+		{
+			"zsh",
+			"bad.sh:3: parse error near `fi'\n",
+			stank.InterpreterError{Path: "bad", Line: 3, Message: "parse error near `fi'"},
+		},
+		{
+			"ksh93",
+			"ksh: bad.sh: syntax error at line 3: `fi' unexpected\n",
+			stank.InterpreterError{Path: "bad", Line: 3, Message: "syntax error: `fi' unexpected"},
+		},
+		{
+			"mksh",
+			"mksh: bad.sh[3]: syntax error: 'fi' unexpected\n",
+			stank.InterpreterError{Path: "bad", Line: 3, Message: "syntax error: 'fi' unexpected"},
+		},
+		{
+			"perl",
+			"syntax error at bad.pl line 1, near \"if true\"\nbad.pl had compilation errors.\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Message: "syntax error, near \"if true\""},
+		},
+		{
+			"ruby",
+			"bad.rb:2: syntax error, unexpected end-of-input\n",
+			stank.InterpreterError{Path: "bad", Line: 2, Message: "syntax error, unexpected end-of-input"},
+		},
+		{
+			"node",
+			"/tmp/bad.js:1\nvar x = (;\n         ^\n\nSyntaxError: Unexpected token ';'\n    at wrapSafe (node:internal/modules/cjs/loader:1464:18)\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Column: 10, Message: "SyntaxError: Unexpected token ';'"},
+		},
+		{
+			"php",
+			"PHP Parse error:  syntax error, unexpected end of file in bad.php on line 3\nErrors parsing bad.php\n",
+			stank.InterpreterError{Path: "bad", Line: 3, Message: "syntax error, unexpected end of file"},
+		},
+		{
+			"python3",
+			"  File \"bad.py\", line 1\n    x = (\n        ^\nSyntaxError: '(' was never closed\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Message: "SyntaxError: '(' was never closed"},
+		},
+		{
+			"fish",
+			"bad.fish (line 1): Missing end to balance this if statement\nif true\n^^\nwarning: Error while reading file bad.fish\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Message: "Missing end to balance this if statement"},
+		},
+		{
+			"elvish",
+			"Compilation error: variable $x not found\n  /tmp/bad.elv:1:6: echo $x\n",
+			stank.InterpreterError{Path: "bad", Line: 1, Column: 6, Message: "Compilation error: variable $x not found"},
+		},
+		{
+			"pwsh",
+			"/tmp/bad.ps1:2:9: Missing closing '}' in statement block or type definition.\n",
+			stank.InterpreterError{Path: "bad", Line: 2, Column: 9, Message: "Missing closing '}' in statement block or type definition."},
+		},
+		{
+			"csh",
+			"\nsomething went wrong\n",
+			stank.InterpreterError{Path: "bad", Message: "something went wrong"},
+		},
+	} {
+		err := stank.ParseInterpreterOutput(stank.Smell{Path: "bad", Interpreter: tc.interpreter}, tc.output, errors.New("exit status 1"))
+		var interpreterError stank.InterpreterError
+
+		if !errors.As(err, &interpreterError) || interpreterError != tc.expected {
+			t.Errorf("expected %v output to parse as %#v, got %#v", tc.interpreter, tc.expected, err)
+		}
+	}
+}
+
+func TestUnixCheckSyntaxLocatesErrors(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash unavailable")
+	}
+
+	pth := filepath.Join(t.TempDir(), "bad")
+
+	if err := os.WriteFile(pth, []byte("#!/bin/bash\nif true; then\necho hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := stank.UnixCheckSyntax(stank.Smell{Path: pth, Interpreter: "bash"})
+	var interpreterError stank.InterpreterError
+
+	if !errors.As(err, &interpreterError) || interpreterError.Path != pth || interpreterError.Line == 0 || interpreterError.Message == "" {
+		t.Errorf("expected a located bash syntax error, got %#v", err)
+	}
+}
+
+func TestCheckSyntaxFallsBackToParser(t *testing.T) {
+	t.Setenv("PATH", "")
+	fsys := fstest.MapFS{
+		"bash": {Data: []byte("#!/bin/bash\na=(1 2\n"), Mode: 0755},
+		"mksh": {Data: []byte("#!/bin/mksh\nif true; then\n"), Mode: 0755},
+		"zsh":  {Data: []byte("#!/bin/zsh\necho (\n"), Mode: 0755},
+		"good": {Data: []byte("#!/bin/bash\na=(1 2)\n"), Mode: 0755},
+	}
+	backends := map[string]string{
+		"bash": "mvdan.cc/sh bash",
+		"mksh": "mvdan.cc/sh mksh",
+		"zsh":  "mvdan.cc/sh zsh",
+	}
+
+	checked := 0
+
+	for analysis, err := range stank.WalkAnalysesFS(fsys, stank.NewWalkConfig(), ".") {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !analysis.Smell.POSIXy {
+			continue
+		}
+
+		checked++
+
+		err = analysis.CheckSyntax()
+		backend, ok := backends[analysis.Smell.Path]
+		var backendError stank.BackendError
+
+		switch {
+		case !ok && err != nil:
+			t.Errorf("expected %v to parse, got %v", analysis.Smell.Path, err)
+		case ok && (!errors.As(err, &backendError) || backendError.Backend != backend):
+			t.Errorf("expected %v syntax error from %v, got %v", analysis.Smell.Path, backend, err)
+		}
+	}
+
+	if checked != len(fsys) {
+		t.Errorf("expected %v scripts, got %v", len(fsys), checked)
+	}
+}
+
+func TestFallbackVariant(t *testing.T) {
+	sniffer := stank.Config{KshInterpreters: map[string]bool{"corpksh": true}}.Overlay(stank.NewSniffer())
+
+	if variant, ok := sniffer.FallbackVariant("mksh"); !ok || variant != syntax.LangMirBSDKorn {
+		t.Errorf("expected mksh to fall back to mksh parsing, got %v", variant)
+	}
+
+	for _, interpreter := range []string{"ksh", "ksh88", "ksh93", "corpksh"} {
+		if _, ok := sniffer.FallbackVariant(interpreter); ok {
+			t.Errorf("expected no parser variant for %v", interpreter)
+		}
+	}
+
+	if _, ok := sniffer.FallbackVariant("fish"); ok {
+		t.Errorf("expected no parser variant for fish")
+	}
+}
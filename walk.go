@@ -0,0 +1,625 @@
+package stank
+
+import (
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WalkConfig bundles together the various options when walking file trees.
+type WalkConfig struct {
+	// Ignores skips paths featuring any of these path components.
+	Ignores []string
+
+	// GitIgnore skips paths excluded by .gitignore files and .git/info/exclude.
+	GitIgnore bool
+
+	// FollowSymlinks descends into symlinked directories, and sniffs symlink targets.
+	FollowSymlinks bool
+
+	// MaxDepth limits traversal to the given number of levels below each root.
+	// Zero denotes unlimited depth.
+	MaxDepth int
+
+	// ProjectConfig applies any ConfigFilename files governing each directory.
+	// Nested configuration overlays the configuration of parent directories.
+	ProjectConfig bool
+
+	// MachineGenerated includes machine-generated files in smell results.
+	MachineGenerated bool
+
+	// Jobs sniffs up to this many files concurrently, for smell results.
+	// Results retain the serial walk order regardless.
+	// Zero or one denotes serial sniffing.
+	Jobs int
+
+	// SniffConfig controls sniffing, for smell results.
+	SniffConfig SniffConfig
+
+	// Sniffer analyzes files, for smell results.
+	Sniffer Sniffer
+}
+
+// NewWalkConfig constructs a WalkConfig with the default exclusions.
+func NewWalkConfig() WalkConfig {
+	return WalkConfig{
+		Ignores:       Ignores(),
+		GitIgnore:     true,
+		ProjectConfig: true,
+		Sniffer:       NewSniffer(),
+	}
+}
+
+// osFS converts an OS path to a file system and a name within it.
+func osFS(pth string) (fs.FS, string) {
+	name := filepath.Base(pth)
+
+	if !fs.ValidPath(name) || name == "." {
+		return os.DirFS(pth), "."
+	}
+
+	return os.DirFS(filepath.Dir(pth)), name
+}
+
+// osRoot converts an OS walk root to a file system and a top level name within it.
+// Directories become the file system root.
+func osRoot(root string) (fs.FS, string) {
+	if fi, err := os.Stat(root); err == nil && fi.IsDir() {
+		return os.DirFS(root), "."
+	}
+
+	return osFS(root)
+}
+
+// osPath converts a file system name, found while walking from top, back into an OS path relative to root.
+func osPath(root string, top string, name string) string {
+	if name == top {
+		return root
+	}
+
+	if top != "." {
+		name = strings.TrimPrefix(name, top+"/")
+	}
+
+	return filepath.Join(root, filepath.FromSlash(name))
+}
+
+// osGitIgnore collects gitignore rules inherited by an OS walk root.
+func osGitIgnore(root string, top string, config WalkConfig) (GitIgnore, error) {
+	if !config.GitIgnore {
+		return GitIgnore{}, nil
+	}
+
+	dir := root
+
+	if top != "." {
+		dir = filepath.Dir(root)
+	}
+
+	return LoadGitIgnoreAncestors(dir)
+}
+
+// osGitIgnored reports whether an OS walk root is itself excluded by gitignore rules declared above it.
+func osGitIgnored(root string, config WalkConfig) (bool, error) {
+	if !config.GitIgnore {
+		return false, nil
+	}
+
+	fi, err := os.Stat(root)
+
+	if err != nil {
+		return false, nil
+	}
+
+	return GitIgnoredByAncestors(root, fi.IsDir())
+}
+
+// Walk traverses the file tree rooted at root, in the manner of filepath.Walk,
+// omitting any paths excluded by the configuration.
+//
+// Walk is a thin wrapper around WalkFS, by way of os.DirFS.
+// Additionally, Walk honors gitignore rules declared above root, within the enclosing git repository,
+// skipping root entirely when those rules exclude it, as well as any project configuration above root.
+func Walk(root string, config WalkConfig, walkFn filepath.WalkFunc) error {
+	seed, err := osProject(root, config)
+
+	if err != nil {
+		return err
+	}
+
+	if IgnoreAny(root, seed.ignores) {
+		return nil
+	}
+
+	if ignored, err2 := osGitIgnored(root, config); err2 != nil || ignored {
+		return err2
+	}
+
+	fsys, top := osRoot(root)
+	gitIgnore, err := osGitIgnore(root, top, config)
+
+	if err != nil {
+		return err
+	}
+
+	return walkFS(fsys, top, config, gitIgnore, seed, func(name string, d fs.DirEntry, err error) error {
+		pth := osPath(root, top, name)
+
+		if err != nil {
+			return walkFn(pth, nil, err)
+		}
+
+		info, err := d.Info()
+		return walkFn(pth, info, err)
+	})
+}
+
+// WalkFS traverses the file tree rooted at root within a file system, in the manner of fs.WalkDir,
+// omitting any paths excluded by the configuration.
+//
+// Excluded directories are skipped wholesale.
+// Explicit roots are exempt from gitignore rules.
+func WalkFS(fsys fs.FS, root string, config WalkConfig, walkFn fs.WalkDirFunc) error {
+	return walkFS(fsys, root, config, GitIgnore{}, newProject(config, nil), walkFn)
+}
+
+// project records the project configuration governing the entries of a directory.
+type project struct {
+	// configs lists the governing configuration files, outermost first.
+	configs []Config
+
+	// sniffer applies the configured tables.
+	sniffer Sniffer
+
+	// ignores applies the configured ignore list.
+	ignores []string
+}
+
+// newProject applies configuration files, outermost first, to a walk configuration.
+func newProject(config WalkConfig, configs []Config) *project {
+	p := &project{sniffer: config.Sniffer, ignores: config.Ignores}
+
+	for _, c := range configs {
+		p = p.overlay(c)
+	}
+
+	return p
+}
+
+// overlay extends a project with a nested configuration file.
+func (o project) overlay(config Config) *project {
+	return &project{
+		configs: append(slices.Clone(o.configs), config),
+		sniffer: config.Overlay(o.sniffer),
+		ignores: config.OverlayIgnores(o.ignores),
+	}
+}
+
+// osProject collects the project configuration declared above an OS walk root.
+//
+// Configuration in a root directory itself is left to the walk.
+func osProject(root string, config WalkConfig) (*project, error) {
+	if !config.ProjectConfig {
+		return newProject(config, nil), nil
+	}
+
+	abs, err := filepath.Abs(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err2 := os.Stat(abs); err2 == nil && fi.IsDir() {
+		if filepath.Dir(abs) == abs {
+			return newProject(config, nil), nil
+		}
+
+		abs = filepath.Dir(abs)
+	}
+
+	configs, err := DiscoverConfigs(abs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newProject(config, configs), nil
+}
+
+// walker tracks the state of a single traversal.
+type walker struct {
+	fsys      fs.FS
+	root      string
+	config    WalkConfig
+	gitIgnore GitIgnore
+	walkFn    fs.WalkDirFunc
+
+	// seed denotes the project configuration governing the root.
+	seed *project
+
+	// projects collects the project configuration of directories featuring a ConfigFilename.
+	projects map[string]*project
+
+	// followed collects symlinked directories already traversed, to guard against cycles.
+	followed []fs.FileInfo
+
+	// stopped denotes an fs.SkipAll request, which must outlast nested symlink traversals.
+	stopped bool
+}
+
+// walkFS implements WalkFS, seeded with any inherited gitignore rules and project configuration.
+func walkFS(fsys fs.FS, root string, config WalkConfig, gitIgnore GitIgnore, seed *project, walkFn fs.WalkDirFunc) error {
+	return newWalker(fsys, root, config, gitIgnore, seed, walkFn).walk()
+}
+
+// newWalker prepares a traversal.
+func newWalker(fsys fs.FS, root string, config WalkConfig, gitIgnore GitIgnore, seed *project, walkFn fs.WalkDirFunc) *walker {
+	return &walker{
+		fsys:      fsys,
+		root:      root,
+		config:    config,
+		gitIgnore: gitIgnore,
+		walkFn:    walkFn,
+		seed:      seed,
+		projects:  map[string]*project{},
+	}
+}
+
+// walk performs the traversal.
+func (o *walker) walk() error {
+	if fi, err := fs.Stat(o.fsys, o.root); err == nil && fi.IsDir() {
+		o.followed = append(o.followed, fi)
+	}
+
+	return fs.WalkDir(o.fsys, o.root, o.visit)
+}
+
+// project locates the project configuration governing a name, from the nearest configured parent directory.
+func (o walker) project(name string) *project {
+	for dir := name; dir != o.root && dir != "." && dir != "/"; {
+		dir = path.Dir(dir)
+
+		if p, ok := o.projects[dir]; ok {
+			return p
+		}
+	}
+
+	return o.seed
+}
+
+// depth computes the number of levels a name resides below the walk root.
+func (o walker) depth(name string) int {
+	if name == o.root {
+		return 0
+	}
+
+	rel := name
+
+	if o.root != "." {
+		rel = strings.TrimPrefix(name, o.root+"/")
+	}
+
+	return strings.Count(rel, "/") + 1
+}
+
+// visit filters entries on behalf of fs.WalkDir.
+func (o *walker) visit(name string, d fs.DirEntry, err error) error {
+	if o.stopped {
+		return fs.SkipAll
+	}
+
+	if err != nil {
+		return o.call(name, d, err)
+	}
+
+	isDir := d.IsDir()
+	depth := o.depth(name)
+
+	if o.config.MaxDepth > 0 && depth > o.config.MaxDepth {
+		if isDir {
+			return fs.SkipDir
+		}
+
+		return nil
+	}
+
+	if name != o.root && (ignoreName(d.Name(), o.project(name).ignores) || o.gitIgnore.Match(name, isDir)) {
+		if isDir {
+			return fs.SkipDir
+		}
+
+		return nil
+	}
+
+	if o.config.FollowSymlinks && d.Type()&fs.ModeSymlink != 0 {
+		return o.follow(name, d)
+	}
+
+	if o.config.GitIgnore && isDir {
+		if contents, err2 := fs.ReadFile(o.fsys, path.Join(name, GitIgnoreFilename)); err2 == nil {
+			o.gitIgnore.Parse(contents, name, "")
+		}
+	}
+
+	if o.config.ProjectConfig && isDir {
+		configName := path.Join(name, ConfigFilename)
+
+		if contents, err2 := fs.ReadFile(o.fsys, configName); err2 == nil {
+			config, err3 := ParseConfig(configName, contents)
+
+			if err3 != nil {
+				// Skip misconfigured directories, rather than misclassify their contents.
+				if err4 := o.call(name, d, err3); err4 != nil {
+					return err4
+				}
+
+				return fs.SkipDir
+			}
+
+			o.projects[name] = o.project(name).overlay(config)
+		}
+	}
+
+	if err2 := o.call(name, d, nil); err2 != nil {
+		return err2
+	}
+
+	if isDir && o.config.MaxDepth > 0 && depth == o.config.MaxDepth {
+		return fs.SkipDir
+	}
+
+	return nil
+}
+
+// follow traverses a symlink target.
+func (o *walker) follow(name string, d fs.DirEntry) error {
+	fi, err := fs.Stat(o.fsys, name)
+
+	if err != nil {
+		// Dangling symlinks are reported as-is.
+		return o.call(name, d, nil)
+	}
+
+	if !fi.IsDir() {
+		return o.call(name, fs.FileInfoToDirEntry(fi), nil)
+	}
+
+	for _, followed := range o.followed {
+		if os.SameFile(followed, fi) {
+			return nil
+		}
+	}
+
+	if target, err2 := fs.ReadLink(o.fsys, name); err2 == nil && !path.IsAbs(target) {
+		resolved := path.Join(path.Dir(name), target)
+
+		if resolved == "." || strings.HasPrefix(name, resolved+"/") {
+			return nil
+		}
+	}
+
+	o.followed = append(o.followed, fi)
+
+	err = fs.WalkDir(o.fsys, name, func(name2 string, d2 fs.DirEntry, err2 error) error {
+		if name2 == name && err2 == nil {
+			// Present the symlink root as a directory, then continue filtering as usual.
+			d2 = fs.FileInfoToDirEntry(fi)
+		}
+
+		return o.visit(name2, d2, err2)
+	})
+
+	if o.stopped {
+		return fs.SkipAll
+	}
+
+	return err
+}
+
+// call invokes the walk callback, noting any fs.SkipAll requests.
+func (o *walker) call(name string, d fs.DirEntry, err error) error {
+	err = o.walkFn(name, d, err)
+
+	if err == fs.SkipAll {
+		o.stopped = true
+	}
+
+	return err
+}
+
+// ignoreName reports whether a base name matches any of the given path components.
+func ignoreName(name string, ignores []string) bool {
+	for _, ignore := range ignores {
+		if name == ignore {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WalkSmells sniffs the files beneath one or more roots,
+// honoring the configured exclusions and project configuration.
+//
+// Directories are omitted, as are machine-generated files unless configured otherwise.
+// Sniffing errors are yielded alongside the affected smell; io.EOF is not considered an error.
+func WalkSmells(config WalkConfig, roots ...string) iter.Seq2[Smell, error] {
+	return smells(walkAnalyses(config, false, roots))
+}
+
+// WalkSmellsFS sniffs the files beneath one or more roots within a file system.
+//
+// See WalkSmells.
+func WalkSmellsFS(fsys fs.FS, config WalkConfig, roots ...string) iter.Seq2[Smell, error] {
+	return smells(walkAnalysesFS(fsys, config, false, roots))
+}
+
+// WalkAnalyses analyzes the files beneath one or more roots,
+// reading the contents of any scripts once, for consumption by multiple checks.
+//
+// See WalkSmells.
+func WalkAnalyses(config WalkConfig, roots ...string) iter.Seq2[Analysis, error] {
+	return walkAnalyses(config, true, roots)
+}
+
+// WalkAnalysesFS analyzes the files beneath one or more roots within a file system.
+//
+// See WalkAnalyses.
+func WalkAnalysesFS(fsys fs.FS, config WalkConfig, roots ...string) iter.Seq2[Analysis, error] {
+	return walkAnalysesFS(fsys, config, true, roots)
+}
+
+// smells reduces analyses to their smells.
+func smells(analyses iter.Seq2[Analysis, error]) iter.Seq2[Smell, error] {
+	return func(yield func(Smell, error) bool) {
+		for analysis, err := range analyses {
+			if !yield(analysis.Smell, err) {
+				return
+			}
+		}
+	}
+}
+
+// walkAnalyses implements WalkSmells and WalkAnalyses, optionally reading script contents.
+func walkAnalyses(config WalkConfig, read bool, roots []string) iter.Seq2[Analysis, error] {
+	return func(yield func(Analysis, error) bool) {
+		for _, root := range roots {
+			seed, err := osProject(root, config)
+
+			if err != nil {
+				if !yield(Analysis{Smell: Smell{Path: root}}, err) {
+					return
+				}
+
+				continue
+			}
+
+			if IgnoreAny(root, seed.ignores) {
+				continue
+			}
+
+			ignored, err := osGitIgnored(root, config)
+
+			if err != nil {
+				if !yield(Analysis{Smell: Smell{Path: root}}, err) {
+					return
+				}
+
+				continue
+			}
+
+			if ignored {
+				continue
+			}
+
+			fsys, top := osRoot(root)
+			gitIgnore, err := osGitIgnore(root, top, config)
+
+			if err != nil {
+				if !yield(Analysis{Smell: Smell{Path: root}}, err) {
+					return
+				}
+
+				continue
+			}
+
+			for analysis, err2 := range walkAnalysesRoot(fsys, top, config, gitIgnore, seed, read) {
+				analysis.Smell.Path = osPath(root, top, analysis.Smell.Path)
+
+				if !yield(analysis, err2) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// walkAnalysesFS implements WalkSmellsFS and WalkAnalysesFS, optionally reading script contents.
+func walkAnalysesFS(fsys fs.FS, config WalkConfig, read bool, roots []string) iter.Seq2[Analysis, error] {
+	return func(yield func(Analysis, error) bool) {
+		for _, root := range roots {
+			for analysis, err := range walkAnalysesRoot(fsys, root, config, GitIgnore{}, newProject(config, nil), read) {
+				if !yield(analysis, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// walkAnalysesRoot analyzes the files beneath a single root, seeded with any inherited gitignore rules and project configuration.
+func walkAnalysesRoot(fsys fs.FS, root string, config WalkConfig, gitIgnore GitIgnore, seed *project, read bool) iter.Seq2[Analysis, error] {
+	type analyzeResult struct {
+		analysis Analysis
+		err      error
+		skip     bool
+	}
+
+	analyze := func(entry walkEntry, err error) analyzeResult {
+		if err != nil {
+			return analyzeResult{analysis: Analysis{Smell: Smell{Path: entry.name}}, err: err}
+		}
+
+		analysis, err := entry.project.sniffer.analyzeFS(fsys, entry.name, config.SniffConfig, config.FollowSymlinks, read)
+		analysis.Configs = entry.project.configs
+
+		if err == io.EOF {
+			err = nil
+		}
+
+		return analyzeResult{
+			analysis: analysis,
+			err:      err,
+			skip:     analysis.Smell.MachineGenerated && !config.MachineGenerated,
+		}
+	}
+
+	return func(yield func(Analysis, error) bool) {
+		for result := range ParallelMap(config.Jobs, walkEntries(fsys, root, config, gitIgnore, seed), analyze) {
+			if result.skip {
+				continue
+			}
+
+			if !yield(result.analysis, result.err) {
+				return
+			}
+		}
+	}
+}
+
+// walkEntry denotes a file found while walking, alongside its governing project configuration.
+type walkEntry struct {
+	name    string
+	project *project
+}
+
+// walkEntries yields the non-directory entries beneath a root, alongside any traversal errors.
+func walkEntries(fsys fs.FS, root string, config WalkConfig, gitIgnore GitIgnore, seed *project) iter.Seq2[walkEntry, error] {
+	return func(yield func(walkEntry, error) bool) {
+		stopped := false
+
+		var w *walker
+
+		w = newWalker(fsys, root, config, gitIgnore, seed, func(name string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				return nil
+			}
+
+			if !yield(walkEntry{name: name, project: w.project(name)}, err) {
+				stopped = true
+				return fs.SkipAll
+			}
+
+			return nil
+		})
+
+		if err := w.walk(); err != nil && !stopped {
+			yield(walkEntry{name: root, project: seed}, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package stank_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mcandre/stank"
+)
+
+func TestWalkFSSniffsMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":           {Data: []byte("dist/\n")},
+		"bin/deploy":           {Data: []byte("#!/bin/bash\necho deploy\n"), Mode: 0755},
+		"dist/deploy":          {Data: []byte("#!/bin/bash\necho deploy\n"), Mode: 0755},
+		"lib/util.sh":          {Data: []byte("echo util\n"), Mode: 0644},
+		"node_modules/x/y.sh":  {Data: []byte("echo y\n"), Mode: 0644},
+		"scripts/hello.py":     {Data: []byte("#!/usr/bin/env python\nprint('hi')\n"), Mode: 0755},
+		"scripts/welcome.bash": {Data: []byte("\xfe\xff#!/bin/bash\r\necho welcome\r\n"), Mode: 0644},
+	}
+
+	sniffer := stank.NewSniffer()
+	var observed []string
+
+	err := stank.WalkFS(fsys, ".", stank.NewWalkConfig(), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		smell, err2 := sniffer.SniffFS(fsys, name, stank.SniffConfig{CRCheck: true})
+
+		if err2 != nil {
+			return err2
+		}
+
+		if smell.POSIXy {
+			observed = append(observed, smell.Path)
+		}
+
+		if smell.Path == "scripts/welcome.bash" && (!smell.BOM || !smell.ContainsCR || smell.Interpreter != "bash") {
+			t.Errorf("expected BOM, CR, bash smell, got %v", smell)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"bin/deploy", "lib/util.sh", "scripts/welcome.bash"}
+
+	if !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected POSIXy paths %v, got %v", expected, observed)
+	}
+}
+
+func TestWalkSmellsOptions(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"top.sh":             "#!/bin/sh\n",
+		"hooks/pre.sample":   "#!/bin/sh\n",
+		"a/mid.sh":           "#!/bin/sh\n",
+		"a/b/deep.sh":        "#!/bin/sh\n",
+		"elsewhere/extra.sh": "#!/bin/sh\n",
+	}
+
+	for name, contents := range files {
+		pth := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(pth, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(root, "elsewhere"), filepath.Join(root, "linked")); err != nil {
+		t.Skip(err)
+	}
+
+	collect := func(config stank.WalkConfig) []string {
+		var observed []string
+
+		for smell, err := range stank.WalkSmells(config, root) {
+			if err != nil {
+				t.Error(err)
+			}
+
+			if smell.POSIXy {
+				rel, err2 := filepath.Rel(root, smell.Path)
+
+				if err2 != nil {
+					t.Fatal(err2)
+				}
+
+				observed = append(observed, filepath.ToSlash(rel))
+			}
+		}
+
+		sort.Strings(observed)
+		return observed
+	}
+
+	config := stank.NewWalkConfig()
+	expected := []string{"a/b/deep.sh", "a/mid.sh", "elsewhere/extra.sh", "top.sh"}
+
+	if observed := collect(config); !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected default walk %v, got %v", expected, observed)
+	}
+
+	config.MaxDepth = 2
+	config.FollowSymlinks = true
+	expected = []string{"a/mid.sh", "elsewhere/extra.sh", "linked/extra.sh", "top.sh"}
+
+	if observed := collect(config); !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected shallow, symlink following walk %v, got %v", expected, observed)
+	}
+
+	config.MaxDepth = 0
+	config.MachineGenerated = true
+	config.FollowSymlinks = false
+	expected = []string{"a/b/deep.sh", "a/mid.sh", "elsewhere/extra.sh", "hooks/pre.sample", "top.sh"}
+
+	if observed := collect(config); !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected machine-generated walk %v, got %v", expected, observed)
+	}
+}